@@ -0,0 +1,318 @@
+package ast
+
+// ModifierFunc replaces a node, e.g. an `unquote(...)` call with the node it evaluates to.
+type ModifierFunc func(Node) Node
+
+// Modify calls `modifier` on every node of the tree below `node`, children first, and returns the modified tree. The
+// nodes with children and the identifiers are copied, so the original tree is left untouched and can be modified again,
+// e.g. when a macro body is expanded a second time.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+	case *Program:
+		copied := *node
+		copied.Statements = modifyStatements(node.Statements, modifier)
+		return modifier(&copied)
+	case *ExpressionStatement:
+		copied := *node
+		copied.Expression = modifyExpression(node.Expression, modifier)
+		return modifier(&copied)
+	case *BlockStatement:
+		copied := *node
+		copied.Statements = modifyStatements(node.Statements, modifier)
+		return modifier(&copied)
+	case *LetStatement:
+		copied := *node
+		copied.Name = modifyIdentifier(node.Name, modifier)
+		copied.Value = modifyExpression(node.Value, modifier)
+		return modifier(&copied)
+	case *ReturnStatement:
+		copied := *node
+		copied.ReturnValue = modifyExpression(node.ReturnValue, modifier)
+		return modifier(&copied)
+	case *WhileStatement:
+		copied := *node
+		copied.Condition = modifyExpression(node.Condition, modifier)
+		copied.Body = modifyBlock(node.Body, modifier)
+		return modifier(&copied)
+	case *ForStatement:
+		copied := *node
+		copied.Variable = modifyIdentifier(node.Variable, modifier)
+		copied.Iterable = modifyExpression(node.Iterable, modifier)
+		copied.Body = modifyBlock(node.Body, modifier)
+		return modifier(&copied)
+	case *ThrowStatement:
+		copied := *node
+		copied.Value = modifyExpression(node.Value, modifier)
+		return modifier(&copied)
+	case *TryExpression:
+		copied := *node
+		copied.Block = modifyBlock(node.Block, modifier)
+		if node.Catch != nil {
+			copied.CatchParameter = modifyIdentifier(node.CatchParameter, modifier)
+			copied.Catch = modifyBlock(node.Catch, modifier)
+		}
+		if node.Finally != nil {
+			copied.Finally = modifyBlock(node.Finally, modifier)
+		}
+		return modifier(&copied)
+	case *PrefixExpression:
+		copied := *node
+		copied.Right = modifyExpression(node.Right, modifier)
+		return modifier(&copied)
+	case *InfixExpression:
+		copied := *node
+		copied.Left = modifyExpression(node.Left, modifier)
+		copied.Right = modifyExpression(node.Right, modifier)
+		return modifier(&copied)
+	case *AssignExpression:
+		copied := *node
+		copied.Target = modifyExpression(node.Target, modifier)
+		copied.Value = modifyExpression(node.Value, modifier)
+		return modifier(&copied)
+	case *IfExpression:
+		copied := *node
+		copied.Condition = modifyExpression(node.Condition, modifier)
+		copied.Consequence = modifyBlock(node.Consequence, modifier)
+		if node.Alternative != nil {
+			copied.Alternative = modifyBlock(node.Alternative, modifier)
+		}
+		return modifier(&copied)
+	case *FunctionLiteral:
+		copied := *node
+		copied.Parameters = modifyIdentifiers(node.Parameters, modifier)
+		if node.Defaults != nil {
+			copied.Defaults = modifyExpressions(node.Defaults, modifier)
+		}
+		if node.Rest != nil {
+			copied.Rest = modifyIdentifier(node.Rest, modifier)
+		}
+		copied.Body = modifyBlock(node.Body, modifier)
+		return modifier(&copied)
+	case *MacroLiteral:
+		copied := *node
+		copied.Parameters = modifyIdentifiers(node.Parameters, modifier)
+		copied.Body = modifyBlock(node.Body, modifier)
+		return modifier(&copied)
+	case *CallExpression:
+		copied := *node
+		copied.Function = modifyExpression(node.Function, modifier)
+		copied.Arguments = modifyExpressions(node.Arguments, modifier)
+		return modifier(&copied)
+	case *ArrayLiteral:
+		copied := *node
+		copied.Elements = modifyExpressions(node.Elements, modifier)
+		return modifier(&copied)
+	case *InterpolatedString:
+		copied := *node
+		copied.Parts = modifyExpressions(node.Parts, modifier)
+		return modifier(&copied)
+	case *IndexExpression:
+		copied := *node
+		copied.Left = modifyExpression(node.Left, modifier)
+		copied.Index = modifyExpression(node.Index, modifier)
+		return modifier(&copied)
+	case *MemberExpression:
+		copied := *node
+		copied.Left = modifyExpression(node.Left, modifier)
+		return modifier(&copied)
+	case *ImportExpression:
+		copied := *node
+		copied.Path = modifyExpression(node.Path, modifier)
+		return modifier(&copied)
+	case *SliceExpression:
+		copied := *node
+		copied.Left = modifyExpression(node.Left, modifier)
+		copied.Low = modifyExpression(node.Low, modifier)
+		copied.High = modifyExpression(node.High, modifier)
+		return modifier(&copied)
+	case *HashLiteral:
+		copied := *node
+		copied.Pairs = make([]HashPair, len(node.Pairs))
+		for i, pair := range node.Pairs {
+			copied.Pairs[i] = HashPair{
+				Key:   modifyExpression(pair.Key, modifier),
+				Value: modifyExpression(pair.Value, modifier),
+			}
+		}
+		return modifier(&copied)
+	case *Identifier:
+		// an identifier is copied too, since each occurrence records where its own binding is
+		copied := *node
+		return modifier(&copied)
+	}
+
+	// the remaining nodes have no children
+	return modifier(node)
+}
+
+// A modifier may replace a node with one of a different kind. Where the tree requires a specific kind, e.g. a
+// statement, a replacement of the wrong kind is dropped and the original node is kept.
+
+func modifyExpression(exp Expression, modifier ModifierFunc) Expression {
+	if exp == nil {
+		return nil
+	}
+
+	if modified, ok := Modify(exp, modifier).(Expression); ok {
+		return modified
+	}
+
+	return exp
+}
+
+func modifyExpressions(exps []Expression, modifier ModifierFunc) []Expression {
+	modified := make([]Expression, len(exps))
+	for i, exp := range exps {
+		modified[i] = modifyExpression(exp, modifier)
+	}
+
+	return modified
+}
+
+func modifyStatements(stmts []Statement, modifier ModifierFunc) []Statement {
+	modified := make([]Statement, len(stmts))
+	for i, stmt := range stmts {
+		modified[i] = stmt
+		if stmt, ok := Modify(stmt, modifier).(Statement); ok {
+			modified[i] = stmt
+		}
+	}
+
+	return modified
+}
+
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if modified, ok := Modify(block, modifier).(*BlockStatement); ok {
+		return modified
+	}
+
+	return block
+}
+
+func modifyIdentifier(ident *Identifier, modifier ModifierFunc) *Identifier {
+	if ident == nil {
+		return nil
+	}
+
+	if modified, ok := Modify(ident, modifier).(*Identifier); ok {
+		return modified
+	}
+
+	return ident
+}
+
+func modifyIdentifiers(idents []*Identifier, modifier ModifierFunc) []*Identifier {
+	modified := make([]*Identifier, len(idents))
+	for i, ident := range idents {
+		modified[i] = modifyIdentifier(ident, modifier)
+	}
+
+	return modified
+}
+
+// Walk visits the tree below `node` in depth-first order, parents first. The children of a node are skipped when
+// `visit` returns false for it.
+func Walk(node Node, visit func(Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, stmt := range node.Statements {
+			Walk(stmt, visit)
+		}
+	case *ExpressionStatement:
+		Walk(node.Expression, visit)
+	case *BlockStatement:
+		for _, stmt := range node.Statements {
+			Walk(stmt, visit)
+		}
+	case *LetStatement:
+		Walk(node.Name, visit)
+		Walk(node.Value, visit)
+	case *ReturnStatement:
+		Walk(node.ReturnValue, visit)
+	case *WhileStatement:
+		Walk(node.Condition, visit)
+		Walk(node.Body, visit)
+	case *ForStatement:
+		Walk(node.Variable, visit)
+		Walk(node.Iterable, visit)
+		Walk(node.Body, visit)
+	case *ThrowStatement:
+		Walk(node.Value, visit)
+	case *TryExpression:
+		Walk(node.Block, visit)
+		if node.Catch != nil {
+			Walk(node.CatchParameter, visit)
+			Walk(node.Catch, visit)
+		}
+		if node.Finally != nil {
+			Walk(node.Finally, visit)
+		}
+	case *PrefixExpression:
+		Walk(node.Right, visit)
+	case *InfixExpression:
+		Walk(node.Left, visit)
+		Walk(node.Right, visit)
+	case *AssignExpression:
+		Walk(node.Target, visit)
+		Walk(node.Value, visit)
+	case *IfExpression:
+		Walk(node.Condition, visit)
+		Walk(node.Consequence, visit)
+		if node.Alternative != nil {
+			Walk(node.Alternative, visit)
+		}
+	case *FunctionLiteral:
+		for i, param := range node.Parameters {
+			Walk(param, visit)
+			if def := node.Default(i); def != nil {
+				Walk(def, visit)
+			}
+		}
+		if node.Rest != nil {
+			Walk(node.Rest, visit)
+		}
+		Walk(node.Body, visit)
+	case *MacroLiteral:
+		for _, param := range node.Parameters {
+			Walk(param, visit)
+		}
+		Walk(node.Body, visit)
+	case *CallExpression:
+		Walk(node.Function, visit)
+		for _, arg := range node.Arguments {
+			Walk(arg, visit)
+		}
+	case *ArrayLiteral:
+		for _, element := range node.Elements {
+			Walk(element, visit)
+		}
+	case *InterpolatedString:
+		for _, part := range node.Parts {
+			Walk(part, visit)
+		}
+	case *IndexExpression:
+		Walk(node.Left, visit)
+		Walk(node.Index, visit)
+	case *MemberExpression:
+		Walk(node.Left, visit)
+	case *ImportExpression:
+		Walk(node.Path, visit)
+	case *SliceExpression:
+		Walk(node.Left, visit)
+		if node.Low != nil {
+			Walk(node.Low, visit)
+		}
+		if node.High != nil {
+			Walk(node.High, visit)
+		}
+	case *HashLiteral:
+		for _, pair := range node.Pairs {
+			Walk(pair.Key, visit)
+			Walk(pair.Value, visit)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func one() Expression { return &IntegerLiteral{Value: 1} }
+func two() Expression { return &IntegerLiteral{Value: 2} }
+
+// turnOneIntoTwo is a modifier which replaces every integer literal `1` with `2`.
+func turnOneIntoTwo(node Node) Node {
+	integer, ok := node.(*IntegerLiteral)
+	if !ok || integer.Value != 1 {
+		return node
+	}
+
+	return &IntegerLiteral{Value: 2}
+}
+
+type ModifyTest struct {
+	input    Node
+	expected Node
+}
+
+func TestModify(t *testing.T) {
+	tests := []ModifyTest{
+		{input: one(), expected: two()},
+		{
+			input:    &Program{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			expected: &Program{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{input: &InfixExpression{Left: one(), Operator: "+", Right: two()}, expected: &InfixExpression{Left: two(), Operator: "+", Right: two()}},
+		{input: &InfixExpression{Left: two(), Operator: "+", Right: one()}, expected: &InfixExpression{Left: two(), Operator: "+", Right: two()}},
+		{input: &PrefixExpression{Operator: "-", Right: one()}, expected: &PrefixExpression{Operator: "-", Right: two()}},
+		{input: &IndexExpression{Left: one(), Index: one()}, expected: &IndexExpression{Left: two(), Index: two()}},
+		{input: &AssignExpression{Target: &Identifier{Value: "x"}, Operator: "+=", Value: one()}, expected: &AssignExpression{Target: &Identifier{Value: "x"}, Operator: "+=", Value: two()}},
+		{
+			input: &IfExpression{
+				Condition:   one(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			expected: &IfExpression{
+				Condition:   two(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{input: &ReturnStatement{ReturnValue: one()}, expected: &ReturnStatement{ReturnValue: two()}},
+		{input: &LetStatement{Value: one()}, expected: &LetStatement{Value: two()}},
+		{
+			input:    &FunctionLiteral{Parameters: []*Identifier{}, Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}}},
+			expected: &FunctionLiteral{Parameters: []*Identifier{}, Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		},
+		{
+			input:    &MacroLiteral{Parameters: []*Identifier{}, Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}}},
+			expected: &MacroLiteral{Parameters: []*Identifier{}, Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		},
+		{input: &CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{one(), two()}}, expected: &CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{two(), two()}}},
+		{input: &ArrayLiteral{Elements: []Expression{one(), one()}}, expected: &ArrayLiteral{Elements: []Expression{two(), two()}}},
+		{input: &HashLiteral{Pairs: []HashPair{{Key: one(), Value: one()}}}, expected: &HashLiteral{Pairs: []HashPair{{Key: two(), Value: two()}}}},
+		{
+			input:    &WhileStatement{Condition: one(), Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}}},
+			expected: &WhileStatement{Condition: two(), Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		},
+		{
+			input:    &ForStatement{Variable: &Identifier{Value: "x"}, Iterable: one(), Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}}},
+			expected: &ForStatement{Variable: &Identifier{Value: "x"}, Iterable: two(), Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+
+		if !reflect.DeepEqual(modified, tt.expected) {
+			t.Errorf("wrong modified node. expected=`%#v`, actual=`%#v`", tt.expected, modified)
+		}
+	}
+}
+
+func TestModifyLeavesOriginalUntouched(t *testing.T) {
+	input := &InfixExpression{Left: one(), Operator: "+", Right: one()}
+
+	Modify(input, turnOneIntoTwo)
+
+	expected := &InfixExpression{Left: one(), Operator: "+", Right: one()}
+	if !reflect.DeepEqual(input, expected) {
+		t.Errorf("original node was modified. actual=`%#v`", input)
+	}
+}
+
+func TestModifyKeepsReplacementsOfTheWrongKind(t *testing.T) {
+	input := &ExpressionStatement{Expression: one()}
+
+	// a statement can't take the place of an expression, so the expression is kept
+	modified := Modify(input, func(node Node) Node {
+		if _, ok := node.(*IntegerLiteral); ok {
+			return &ReturnStatement{}
+		}
+		return node
+	})
+
+	if !reflect.DeepEqual(modified, input) {
+		t.Errorf("wrong modified node. expected=`%#v`, actual=`%#v`", input, modified)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	program := &Program{Statements: []Statement{
+		&LetStatement{Name: &Identifier{Value: "f"}, Value: &FunctionLiteral{
+			Parameters: []*Identifier{{Value: "x"}},
+			Body:       &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+		}},
+		&ExpressionStatement{Expression: &CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{two()}}},
+	}}
+
+	visited := []string{}
+	Walk(program, func(node Node) bool {
+		switch node := node.(type) {
+		case *Identifier:
+			visited = append(visited, node.Value)
+		case *IntegerLiteral:
+			visited = append(visited, fmt.Sprint(node.Value))
+		case *FunctionLiteral:
+			visited = append(visited, "fn")
+			return false
+		}
+		return true
+	})
+
+	expected := []string{"f", "fn", "f", "2"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong visited nodes. expected=`%v`, actual=`%v`", expected, visited)
+	}
+}
@@ -0,0 +1,252 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type Instructions []byte
+
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i += 1
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), operandCount)
+	}
+
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+
+	OpTrue
+	OpFalse
+	OpNull
+
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpLessThanOrEqual
+	OpGreaterThan
+	OpGreaterThanOrEqual
+
+	OpMinus
+	OpBang
+
+	OpJumpNotTruthy
+	OpJump
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetFree
+	OpSetFree
+	OpAssignGlobal
+	OpCaptureLocal
+	OpCaptureFree
+	OpCurrentClosure
+	OpGetGlobalOr
+	OpGetLocalOr
+	OpGetFreeOr
+
+	OpArray
+	OpHash
+	OpIndex
+	OpSlice
+	OpSetIndex
+	OpUpdateIndex
+	OpIterate
+	OpInterpolate
+
+	OpCall
+	OpReturnValue
+	OpReturn
+	OpClosure
+
+	OpTry
+	OpEndTry
+	OpThrow
+	OpCatch
+
+	OpImport
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int // width of each operand in bytes
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {Name: "OpConstant", OperandWidths: []int{2}}, // index of the constant
+	OpPop:      {Name: "OpPop", OperandWidths: []int{}},
+
+	OpAdd: {Name: "OpAdd", OperandWidths: []int{}},
+	OpSub: {Name: "OpSub", OperandWidths: []int{}},
+	OpMul: {Name: "OpMul", OperandWidths: []int{}},
+	OpDiv: {Name: "OpDiv", OperandWidths: []int{}},
+	OpMod: {Name: "OpMod", OperandWidths: []int{}},
+
+	OpTrue:  {Name: "OpTrue", OperandWidths: []int{}},
+	OpFalse: {Name: "OpFalse", OperandWidths: []int{}},
+	OpNull:  {Name: "OpNull", OperandWidths: []int{}},
+
+	OpEqual:              {Name: "OpEqual", OperandWidths: []int{}},
+	OpNotEqual:           {Name: "OpNotEqual", OperandWidths: []int{}},
+	OpLessThan:           {Name: "OpLessThan", OperandWidths: []int{}},
+	OpLessThanOrEqual:    {Name: "OpLessThanOrEqual", OperandWidths: []int{}},
+	OpGreaterThan:        {Name: "OpGreaterThan", OperandWidths: []int{}},
+	OpGreaterThanOrEqual: {Name: "OpGreaterThanOrEqual", OperandWidths: []int{}},
+
+	OpMinus: {Name: "OpMinus", OperandWidths: []int{}},
+	OpBang:  {Name: "OpBang", OperandWidths: []int{}},
+
+	OpJumpNotTruthy: {Name: "OpJumpNotTruthy", OperandWidths: []int{2}}, // jump target
+	OpJump:          {Name: "OpJump", OperandWidths: []int{2}},          // jump target
+
+	OpGetGlobal:      {Name: "OpGetGlobal", OperandWidths: []int{2}},    // index of the global
+	OpSetGlobal:      {Name: "OpSetGlobal", OperandWidths: []int{2}},    // index of the global
+	OpGetLocal:       {Name: "OpGetLocal", OperandWidths: []int{1}},     // index of the local
+	OpSetLocal:       {Name: "OpSetLocal", OperandWidths: []int{1}},     // index of the local
+	OpGetFree:        {Name: "OpGetFree", OperandWidths: []int{1}},      // index of the free variable
+	OpSetFree:        {Name: "OpSetFree", OperandWidths: []int{1}},      // index of the free variable
+	OpAssignGlobal:   {Name: "OpAssignGlobal", OperandWidths: []int{2}}, // index of the global, which must be set already
+	OpCaptureLocal:   {Name: "OpCaptureLocal", OperandWidths: []int{1}}, // index of the local, pushed as a cell for a closure
+	OpCaptureFree:    {Name: "OpCaptureFree", OperandWidths: []int{1}},  // index of the free variable, pushed without unwrapping its cell
+	OpCurrentClosure: {Name: "OpCurrentClosure", OperandWidths: []int{}},
+
+	// a variable that may not be bound yet, e.g. by a `let` in a branch that didn't run, is read with a fallback: when it
+	// is bound, its value is pushed and the VM jumps over the code that loads the binding it falls back to
+	OpGetGlobalOr: {Name: "OpGetGlobalOr", OperandWidths: []int{2, 2}}, // index of the global, position after the fallback
+	OpGetLocalOr:  {Name: "OpGetLocalOr", OperandWidths: []int{1, 2}},  // index of the local, position after the fallback
+	OpGetFreeOr:   {Name: "OpGetFreeOr", OperandWidths: []int{1, 2}},   // index of the free variable, position after the fallback
+
+	OpArray:       {Name: "OpArray", OperandWidths: []int{2}}, // number of elements
+	OpHash:        {Name: "OpHash", OperandWidths: []int{2}},  // number of keys and values
+	OpIndex:       {Name: "OpIndex", OperandWidths: []int{}},
+	OpSlice:       {Name: "OpSlice", OperandWidths: []int{}}, // takes the value and both bounds, a missing bound being null
+	OpSetIndex:    {Name: "OpSetIndex", OperandWidths: []int{}},
+	OpUpdateIndex: {Name: "OpUpdateIndex", OperandWidths: []int{1}}, // opcode of the operator of a compound assignment
+	OpIterate:     {Name: "OpIterate", OperandWidths: []int{}},      // turns the value on the stack into the array a `for` loop visits
+	OpInterpolate: {Name: "OpInterpolate", OperandWidths: []int{2}}, // number of parts of an interpolated string
+
+	OpCall:        {Name: "OpCall", OperandWidths: []int{1}}, // number of arguments
+	OpReturnValue: {Name: "OpReturnValue", OperandWidths: []int{}},
+	OpReturn:      {Name: "OpReturn", OperandWidths: []int{}},
+	OpClosure:     {Name: "OpClosure", OperandWidths: []int{2, 1}}, // index of the function constant, number of free variables
+
+	OpTry:    {Name: "OpTry", OperandWidths: []int{2}}, // position of the code that handles an error of the try block
+	OpEndTry: {Name: "OpEndTry", OperandWidths: []int{}},
+	OpThrow:  {Name: "OpThrow", OperandWidths: []int{}}, // an error on the stack is raised again as it is
+	OpCatch:  {Name: "OpCatch", OperandWidths: []int{}}, // turns the error on the stack into the error value of a `catch` block
+
+	OpImport: {Name: "OpImport", OperandWidths: []int{2}}, // index of the constant with the name of the importing file
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// Make encodes an instruction, with its operands in big endian.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction, and returns them with the number of bytes read.
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
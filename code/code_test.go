@@ -0,0 +1,92 @@
+package code
+
+import "testing"
+
+type MakeTest struct {
+	op       Opcode
+	operands []int
+	expected []byte
+}
+
+func TestMake(t *testing.T) {
+	tests := []MakeTest{
+		{op: OpConstant, operands: []int{65534}, expected: []byte{byte(OpConstant), 255, 254}},
+		{op: OpAdd, operands: []int{}, expected: []byte{byte(OpAdd)}},
+		{op: OpGetLocal, operands: []int{255}, expected: []byte{byte(OpGetLocal), 255}},
+		{op: OpClosure, operands: []int{65534, 255}, expected: []byte{byte(OpClosure), 255, 254, 255}},
+	}
+
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+
+		if len(instruction) != len(tt.expected) {
+			t.Fatalf("wrong instruction length. expected=`%d`, actual=`%d`", len(tt.expected), len(instruction))
+		}
+
+		for i, b := range tt.expected {
+			if instruction[i] != b {
+				t.Errorf("wrong byte at pos %d. expected=`%d`, actual=`%d`", i, b, instruction[i])
+			}
+		}
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	instructions := []Instructions{
+		Make(OpAdd),
+		Make(OpGetLocal, 1),
+		Make(OpConstant, 2),
+		Make(OpConstant, 65535),
+		Make(OpClosure, 65535, 255),
+	}
+
+	expected := `0000 OpAdd
+0001 OpGetLocal 1
+0003 OpConstant 2
+0006 OpConstant 65535
+0009 OpClosure 65535 255
+`
+
+	concatted := Instructions{}
+	for _, ins := range instructions {
+		concatted = append(concatted, ins...)
+	}
+
+	if concatted.String() != expected {
+		t.Errorf("instructions wrongly formatted. expected=`%q`, actual=`%q`", expected, concatted.String())
+	}
+}
+
+type ReadOperandsTest struct {
+	op        Opcode
+	operands  []int
+	bytesRead int
+}
+
+func TestReadOperands(t *testing.T) {
+	tests := []ReadOperandsTest{
+		{op: OpConstant, operands: []int{65535}, bytesRead: 2},
+		{op: OpGetLocal, operands: []int{255}, bytesRead: 1},
+		{op: OpClosure, operands: []int{65535, 255}, bytesRead: 3},
+	}
+
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+
+		def, err := Lookup(byte(tt.op))
+		if err != nil {
+			t.Fatalf("definition not found: %q", err)
+		}
+
+		operandsRead, n := ReadOperands(def, instruction[1:])
+		if n != tt.bytesRead {
+			t.Fatalf("wrong number of bytes read. expected=`%d`, actual=`%d`", tt.bytesRead, n)
+		}
+
+		for i, expected := range tt.operands {
+			if operandsRead[i] != expected {
+				t.Errorf("wrong operand. expected=`%d`, actual=`%d`", expected, operandsRead[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,1110 @@
+package compiler
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/code"
+	"monkey/evaluator"
+	"monkey/object"
+	"monkey/token"
+	"strings"
+)
+
+// infixOperators maps the infix operators to the opcode which applies them.
+var infixOperators = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	"<=": code.OpLessThanOrEqual,
+	">":  code.OpGreaterThan,
+	">=": code.OpGreaterThanOrEqual,
+}
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+type CompilationScope struct {
+	instructions        code.Instructions
+	positions           []object.InstructionPosition
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+
+	loops []*loop     // enclosing loops, the innermost one last
+	tries []*tryBlock // enclosing blocks whose errors are handled by a `try`, the innermost one last
+}
+
+type loop struct {
+	start      int   // position `continue` jumps to
+	breakJumps []int // positions of the jumps of `break`, patched once the end of the loop is known
+}
+
+// tryBlock is a block that runs with an error handler, which must be removed when `return`, `break` or `continue`
+// leave the block early. Leaving it also runs the `finally` block.
+type tryBlock struct {
+	finally *ast.BlockStatement // nil without `finally`
+	loops   int                 // number of enclosing loops at the start of the block
+}
+
+type Compiler struct {
+	constants   []object.Object
+	interned    map[interface{}]int // index of each constant that is shared by equal values
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	err error          // the first instruction that can't be encoded, reported at the end of `Compile`
+	pos token.Position // position of the innermost node being compiled, which the emitted instructions come from
+}
+
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	GlobalNames  []string // names of the globals, used in error messages
+	Positions    []object.InstructionPosition
+}
+
+func New() *Compiler {
+	return NewWithState(NewSymbolTable(), []object.Object{})
+}
+
+// NewWithState creates a compiler which continues from the symbols and constants of a previous compilation, e.g. the
+// previous line in the REPL.
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	mainScope := CompilationScope{
+		instructions: code.Instructions{},
+	}
+
+	interned := make(map[interface{}]int)
+	for i, constant := range constants {
+		if key, ok := internKey(constant); ok {
+			interned[key] = i
+		}
+	}
+
+	return &Compiler{
+		constants:   constants,
+		interned:    interned,
+		symbolTable: s,
+		scopes:      []CompilationScope{mainScope},
+	}
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	// like in the evaluator, an error points at the innermost node, so each instruction has the position of the
+	// innermost node it was compiled for
+	pos := c.pos
+	if node != nil {
+		c.pos = node.Pos()
+	}
+
+	err := c.compile(node)
+	c.pos = pos
+	if err == nil {
+		err = c.err
+	}
+
+	return err
+}
+
+func (c *Compiler) compile(node ast.Node) error {
+	switch node := node.(type) {
+	case *ast.Program:
+		c.declareBindings(node)
+		for _, s := range node.Statements {
+			err := c.Compile(s)
+			if err != nil {
+				return err
+			}
+		}
+	case *ast.ExpressionStatement:
+		err := c.Compile(node.Expression)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			err := c.Compile(s)
+			if err != nil {
+				return err
+			}
+		}
+	case *ast.LetStatement:
+		// the name is declared before, so `let a = a + 1;` refers to `a` of this scope, which falls back to the `a`
+		// outside of it while it is not bound yet
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		symbol := c.symbolTable.Define(node.Name.Value)
+		c.storeSymbol(symbol)
+	case *ast.ReturnStatement:
+		err := c.Compile(node.ReturnValue)
+		if err != nil {
+			return err
+		}
+
+		err = c.leaveTryBlocks(0)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+		if !ok {
+			symbol = c.defineUnresolved(node.Value)
+		}
+		c.loadBinding(symbol)
+	case *ast.IntegerLiteral:
+		integer := &object.Integer{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(integer))
+	case *ast.FloatLiteral:
+		float := &object.Float{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(float))
+	case *ast.StringLiteral:
+		str := &object.String{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(str))
+	case *ast.BooleanLiteral:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.PrefixExpression:
+		err := c.Compile(node.Right)
+		if err != nil {
+			return err
+		}
+
+		switch node.Operator {
+		case "!":
+			c.emit(code.OpBang)
+		case "-":
+			c.emit(code.OpMinus)
+		default:
+			return fmt.Errorf("unknown operator: %s", node.Operator)
+		}
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.compileLogicalExpression(node)
+		}
+
+		op, ok := infixOperators[node.Operator]
+		if !ok {
+			return fmt.Errorf("unknown operator: %s", node.Operator)
+		}
+
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(node.Right)
+		if err != nil {
+			return err
+		}
+
+		c.emit(op)
+	case *ast.AssignExpression:
+		return c.compileAssignExpression(node)
+	case *ast.IfExpression:
+		return c.compileIfExpression(node)
+	case *ast.TryExpression:
+		return c.compileTryExpression(node)
+	case *ast.ThrowStatement:
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpThrow)
+	case *ast.WhileStatement:
+		return c.compileWhileStatement(node)
+	case *ast.ForStatement:
+		return c.compileForStatement(node)
+	case *ast.BreakStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return fmt.Errorf("`break` outside of a loop")
+		}
+
+		err := c.leaveTryBlocks(len(c.scopes[c.scopeIndex].loops))
+		if err != nil {
+			return err
+		}
+		loop.breakJumps = append(loop.breakJumps, c.emit(code.OpJump, 9999))
+	case *ast.ContinueStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return fmt.Errorf("`continue` outside of a loop")
+		}
+
+		err := c.leaveTryBlocks(len(c.scopes[c.scopeIndex].loops))
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpJump, loop.start)
+	case *ast.ArrayLiteral:
+		for _, e := range node.Elements {
+			err := c.Compile(e)
+			if err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+	case *ast.InterpolatedString:
+		for _, part := range node.Parts {
+			err := c.Compile(part)
+			if err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpInterpolate, len(node.Parts))
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			err := c.Compile(pair.Key)
+			if err != nil {
+				return err
+			}
+
+			err = c.Compile(pair.Value)
+			if err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(node.Pairs)*2)
+	case *ast.IndexExpression:
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(node.Index)
+		if err != nil {
+			return err
+		}
+
+		c.emit(code.OpIndex)
+	case *ast.SliceExpression:
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
+
+		for _, bound := range []ast.Expression{node.Low, node.High} {
+			if bound == nil {
+				c.emit(code.OpNull)
+				continue
+			}
+
+			err := c.Compile(bound)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpSlice)
+	case *ast.MemberExpression:
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
+
+		member := &object.String{Value: node.Member.Value}
+		c.emit(code.OpConstant, c.addConstant(member))
+		c.emit(code.OpIndex)
+	case *ast.ImportExpression:
+		err := c.Compile(node.Path)
+		if err != nil {
+			return err
+		}
+
+		from := &object.String{Value: node.Pos().Filename}
+		c.emit(code.OpImport, c.addConstant(from))
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(node)
+	case *ast.CallExpression:
+		if ident, ok := node.Function.(*ast.Identifier); ok && ident.Value == "quote" {
+			return fmt.Errorf("compiling quote is not supported")
+		}
+
+		err := c.Compile(node.Function)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range node.Arguments {
+			err := c.Compile(a)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpCall, len(node.Arguments))
+	default:
+		return fmt.Errorf("compiling %T is not supported", node)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	err := c.Compile(node.Condition)
+	if err != nil {
+		return err
+	}
+
+	// the jump targets are not known yet, so they are patched later
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	err = c.compileBlockValue(node.Consequence)
+	if err != nil {
+		return err
+	}
+
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	afterConsequencePos := len(c.currentInstructions())
+	c.changeOperand(jumpNotTruthyPos, afterConsequencePos)
+
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else {
+		err := c.compileBlockValue(node.Alternative)
+		if err != nil {
+			return err
+		}
+	}
+
+	afterAlternativePos := len(c.currentInstructions())
+	c.changeOperand(jumpPos, afterAlternativePos)
+
+	return nil
+}
+
+// compileTryExpression compiles `try { ... } catch (e) { ... } finally { ... }` like this, where a handler is
+// the position the VM continues at, with the error on the stack, when an error happens after `OpTry`:
+//
+//	OpTry <catch>; <try block>; OpEndTry; OpJump <finally>
+//	catch: OpCatch; let e = <error value>; OpTry <rethrow>; <catch block>; OpEndTry
+//	finally: <finally block>; OpJump <end>
+//	rethrow: <finally block>; OpThrow
+//	end:
+//
+// The value of the expression is the value of the try or the catch block. Without `catch`, the handler of the try
+// block is <rethrow>, and without `finally` there is no <rethrow>. The handlers get the error itself, so <rethrow>
+// raises it again with its position and stack.
+func (c *Compiler) compileTryExpression(node *ast.TryExpression) error {
+	tryPos := c.emit(code.OpTry, 9999)
+
+	err := c.compileTryBlock(node.Block, node.Finally)
+	if err != nil {
+		return err
+	}
+
+	c.emit(code.OpEndTry)
+
+	var rethrowTryPositions []int
+	if node.Catch == nil {
+		rethrowTryPositions = append(rethrowTryPositions, tryPos)
+	} else {
+		jumpPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(tryPos, len(c.currentInstructions()))
+
+		c.emit(code.OpCatch)
+		c.storeSymbol(c.symbolTable.Define(node.CatchParameter.Value))
+
+		if node.Finally == nil {
+			err = c.compileBlockValue(node.Catch)
+		} else {
+			rethrowTryPositions = append(rethrowTryPositions, c.emit(code.OpTry, 9999))
+			err = c.compileTryBlock(node.Catch, node.Finally)
+			c.emit(code.OpEndTry)
+		}
+		if err != nil {
+			return err
+		}
+
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+	}
+
+	if node.Finally == nil {
+		return nil
+	}
+
+	err = c.Compile(node.Finally)
+	if err != nil {
+		return err
+	}
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	for _, pos := range rethrowTryPositions {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+
+	err = c.Compile(node.Finally)
+	if err != nil {
+		return err
+	}
+	c.emit(code.OpThrow)
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileTryBlock compiles a block that runs with an error handler, leaving its value on the stack.
+func (c *Compiler) compileTryBlock(block *ast.BlockStatement, finally *ast.BlockStatement) error {
+	scope := &c.scopes[c.scopeIndex]
+	scope.tries = append(scope.tries, &tryBlock{finally: finally, loops: len(scope.loops)})
+
+	err := c.compileBlockValue(block)
+
+	scope = &c.scopes[c.scopeIndex]
+	scope.tries = scope.tries[:len(scope.tries)-1]
+
+	return err
+}
+
+// leaveTryBlocks compiles leaving the try blocks that started inside at least `loops` loops, innermost first: their
+// error handlers are removed, and their `finally` blocks run. `return` leaves all of them, while `break` and
+// `continue` only leave the ones inside the innermost loop.
+func (c *Compiler) leaveTryBlocks(loops int) error {
+	tries := c.scopes[c.scopeIndex].tries
+
+	for i := len(tries) - 1; i >= 0 && tries[i].loops >= loops; i-- {
+		c.emit(code.OpEndTry)
+		if tries[i].finally == nil {
+			continue
+		}
+
+		// the `finally` block runs outside of the blocks it leaves
+		c.scopes[c.scopeIndex].tries = tries[:i]
+		err := c.Compile(tries[i].finally)
+		c.scopes[c.scopeIndex].tries = tries
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compileLogicalExpression compiles `&&` and `||`, which skip their right operand once the left one decides the
+// result: a falsy operand makes `&&` false, a truthy one makes `||` true.
+func (c *Compiler) compileLogicalExpression(node *ast.InfixExpression) error {
+	decided, undecided := code.OpFalse, code.OpTrue
+	if node.Operator == "||" {
+		decided, undecided = code.OpTrue, code.OpFalse
+	}
+
+	decidingJumps := []int{}
+	for _, operand := range []ast.Expression{node.Left, node.Right} {
+		err := c.Compile(operand)
+		if err != nil {
+			return err
+		}
+
+		if node.Operator == "||" {
+			c.emit(code.OpBang)
+		}
+		decidingJumps = append(decidingJumps, c.emit(code.OpJumpNotTruthy, 9999))
+	}
+
+	c.emit(undecided)
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	for _, pos := range decidingJumps {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+	c.emit(decided)
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+	return nil
+}
+
+func (c *Compiler) compileAssignExpression(node *ast.AssignExpression) error {
+	operator := strings.TrimSuffix(node.Operator, "=")
+	op, ok := infixOperators[operator]
+	if operator != "" && !ok {
+		return fmt.Errorf("unknown operator: %s", node.Operator)
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(target.Value)
+		if !ok {
+			// it may be a global defined later, which is checked when the assignment runs
+			symbol = c.defineUnresolved(target.Value)
+		}
+
+		if symbol.Scope == BuiltInScope || c.isFunctionName(symbol) {
+			return fmt.Errorf("cannot assign to %s", target.Value)
+		}
+
+		if operator != "" {
+			c.loadBinding(symbol)
+		}
+
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		if operator != "" {
+			c.emit(op)
+		}
+
+		// the assigned value is the value of the expression
+		c.assignSymbol(symbol)
+		c.loadSymbol(symbol)
+	case *ast.IndexExpression:
+		err := c.Compile(target.Left)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(target.Index)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		if operator != "" {
+			c.emit(code.OpUpdateIndex, int(op))
+		} else {
+			c.emit(code.OpSetIndex)
+		}
+	default:
+		return fmt.Errorf("cannot assign to %s", node.Target)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileWhileStatement(node *ast.WhileStatement) error {
+	start := len(c.currentInstructions())
+
+	err := c.Compile(node.Condition)
+	if err != nil {
+		return err
+	}
+
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	err = c.compileLoopBody(start, node.Body)
+	if err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileForStatement compiles `for (x in iterable) { ... }` like this loop, where `items` and `index` are hidden
+// variables:
+//
+//	let items = <items of iterable>; let index = 0;
+//	while (index < len(items)) { let x = items[index]; let index = index + 1; ... }
+func (c *Compiler) compileForStatement(node *ast.ForStatement) error {
+	// the names can't clash with identifiers, and nested loops get their own variables
+	depth := len(c.scopes[c.scopeIndex].loops)
+	items := c.symbolTable.Define(fmt.Sprintf("$items%d", depth))
+	index := c.symbolTable.Define(fmt.Sprintf("$index%d", depth))
+	lenBuiltIn, _ := evaluator.LookupBuiltIn("len")
+
+	err := c.Compile(node.Iterable)
+	if err != nil {
+		return err
+	}
+	c.emit(code.OpIterate)
+	c.storeSymbol(items)
+
+	c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: 0}))
+	c.storeSymbol(index)
+
+	start := len(c.currentInstructions())
+
+	c.loadSymbol(index)
+	c.emit(code.OpConstant, c.addConstant(lenBuiltIn))
+	c.loadSymbol(items)
+	c.emit(code.OpCall, 1)
+	c.emit(code.OpLessThan)
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	c.loadSymbol(items)
+	c.loadSymbol(index)
+	c.emit(code.OpIndex)
+	c.storeSymbol(c.symbolTable.Define(node.Variable.Value))
+
+	c.loadSymbol(index)
+	c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: 1}))
+	c.emit(code.OpAdd)
+	c.storeSymbol(index)
+
+	err = c.compileLoopBody(start, node.Body)
+	if err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileLoopBody compiles the body of a loop which starts at `start`, followed by the jump back to the start.
+func (c *Compiler) compileLoopBody(start int, body *ast.BlockStatement) error {
+	scope := &c.scopes[c.scopeIndex]
+	scope.loops = append(scope.loops, &loop{start: start})
+
+	err := c.Compile(body)
+	if err != nil {
+		return err
+	}
+
+	c.emit(code.OpJump, start)
+
+	scope = &c.scopes[c.scopeIndex]
+	loop := scope.loops[len(scope.loops)-1]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+
+	end := len(c.currentInstructions())
+	for _, pos := range loop.breakJumps {
+		c.changeOperand(pos, end)
+	}
+
+	return nil
+}
+
+func (c *Compiler) currentLoop() *loop {
+	loops := c.scopes[c.scopeIndex].loops
+	if len(loops) == 0 {
+		return nil
+	}
+
+	return loops[len(loops)-1]
+}
+
+// compileBlockValue compiles a block whose last expression is its value, leaving that value on the stack.
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	start := len(c.currentInstructions())
+
+	err := c.Compile(block)
+	if err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) && c.scopes[c.scopeIndex].lastInstruction.Position >= start {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileFunctionLiteral(node *ast.FunctionLiteral) error {
+	c.enterScope()
+
+	if node.Name != "" {
+		c.symbolTable.DefineFunctionName(node.Name)
+	}
+
+	params := make([]Symbol, len(node.Parameters))
+	for i, p := range node.Parameters {
+		params[i] = c.symbolTable.Define(p.Value)
+	}
+	if node.Rest != nil {
+		c.symbolTable.Define(node.Rest.Value)
+	}
+	c.symbolTable.numParameters = c.symbolTable.numDefinitions
+	c.declareBindings(node.Body)
+
+	// a call that leaves out arguments starts at the default value of the first missing one
+	var entries []int
+	for i, symbol := range params {
+		def := node.Default(i)
+		if def == nil {
+			continue
+		}
+
+		entries = append(entries, len(c.currentInstructions()))
+		err := c.Compile(def)
+		if err != nil {
+			return err
+		}
+		c.storeSymbol(symbol)
+	}
+	if entries != nil {
+		entries = append(entries, len(c.currentInstructions()))
+	}
+
+	err := c.Compile(node.Body)
+	if err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) {
+		c.replaceLastPopWithReturn()
+	}
+	if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpReturn)
+	}
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.numDefinitions
+	localNames := c.symbolTable.Names()
+	positions := c.scopes[c.scopeIndex].positions
+	instructions := c.leaveScope()
+
+	freeNames := make([]string, len(freeSymbols))
+	for i, s := range freeSymbols {
+		c.captureSymbol(s)
+		freeNames[i] = s.Name
+	}
+
+	compiledFn := &object.CompiledFunction{
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		Rest:          node.Rest != nil,
+		Name:          node.Name,
+		Entries:       entries,
+		LocalNames:    localNames,
+		FreeNames:     freeNames,
+		Positions:     positions,
+	}
+
+	fnIndex := c.addConstant(compiledFn)
+	c.emit(code.OpClosure, fnIndex, len(freeSymbols))
+
+	return nil
+}
+
+// declareBindings defines the names that `node` binds in the current scope, without the ones of nested functions,
+// before any code of the scope is compiled. Like in the evaluator, a function then refers to the variable of its scope
+// even when the variable is bound after the function.
+func (c *Compiler) declareBindings(node ast.Node) {
+	ast.Walk(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			c.symbolTable.Define(node.Name.Value)
+		case *ast.ForStatement:
+			c.symbolTable.Define(node.Variable.Value)
+		case *ast.TryExpression:
+			if node.CatchParameter != nil {
+				c.symbolTable.Define(node.CatchParameter.Value)
+			}
+		case *ast.FunctionLiteral, *ast.MacroLiteral:
+			return false
+		}
+
+		return true
+	})
+}
+
+// loadBinding loads the variable of `s`. A variable that may not be bound yet falls back to the binding of its name
+// outside of its scope, or to the built-in function of that name, like `object.Environment.GetAt` does.
+func (c *Compiler) loadBinding(s Symbol) {
+	fallback, ok := c.fallback(c.symbolTable, s)
+	if !ok {
+		c.loadSymbol(s)
+		return
+	}
+
+	var op code.Opcode
+	switch s.Scope {
+	case GlobalScope:
+		op = code.OpGetGlobalOr
+	case LocalScope:
+		op = code.OpGetLocalOr
+	case FreeScope:
+		op = code.OpGetFreeOr
+	}
+
+	pos := c.emit(op, s.Index, 9999)
+	c.loadBinding(fallback)
+	c.replaceInstruction(pos, c.makeInstruction(op, s.Index, len(c.currentInstructions())))
+}
+
+// fallback returns the symbol that a read of `s` in the scope of `table` falls back to while `s` is not bound.
+func (c *Compiler) fallback(table *SymbolTable, s Symbol) (Symbol, bool) {
+	switch s.Scope {
+	case GlobalScope:
+		return c.builtInFallback(s.Name)
+	case LocalScope:
+		if s.Index < table.numParameters {
+			return Symbol{}, false
+		}
+
+		outer, ok := table.Outer.Resolve(s.Name)
+		if !ok {
+			return c.builtInFallback(s.Name)
+		}
+		return table.capture(outer), true
+	case FreeScope:
+		outer, ok := c.fallback(table.Outer, table.FreeSymbols[s.Index])
+		if !ok {
+			return Symbol{}, false
+		}
+		return table.capture(outer), true
+	}
+
+	return Symbol{}, false
+}
+
+// builtInFallback returns the symbol of the built-in function `name`, without binding the name, which belongs to a
+// variable that shadows the built-in function.
+func (c *Compiler) builtInFallback(name string) (Symbol, bool) {
+	builtIn, ok := evaluator.LookupBuiltIn(name)
+	if !ok {
+		return Symbol{}, false
+	}
+
+	return Symbol{Name: name, Scope: BuiltInScope, Index: c.addConstant(builtIn)}, true
+}
+
+// defineUnresolved handles a name that is not bound yet. It is either a built-in function, or a global that may still be
+// defined later, e.g. by a function declared after the current one. Reading a global that is never set is a runtime
+// error, just like in the evaluator.
+func (c *Compiler) defineUnresolved(name string) Symbol {
+	if builtIn, ok := evaluator.LookupBuiltIn(name); ok {
+		return c.symbolTable.DefineBuiltIn(name, c.addConstant(builtIn))
+	}
+
+	return c.symbolTable.DefineGlobal(name)
+}
+
+func (c *Compiler) storeSymbol(s Symbol) {
+	if s.Scope == GlobalScope {
+		c.emit(code.OpSetGlobal, s.Index)
+	} else {
+		c.emit(code.OpSetLocal, s.Index)
+	}
+}
+
+// assignSymbol updates an existing binding, unlike `storeSymbol` which creates it.
+func (c *Compiler) assignSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpAssignGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpSetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpSetFree, s.Index)
+	}
+}
+
+// captureSymbol loads a free variable of a new closure. Variables are captured as cells, so assignments are shared.
+func (c *Compiler) captureSymbol(s Symbol) {
+	switch s.Scope {
+	case LocalScope:
+		c.emit(code.OpCaptureLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpCaptureFree, s.Index)
+	default:
+		c.loadSymbol(s)
+	}
+}
+
+// isFunctionName reports whether `s` refers to the function being defined, which is not a variable of its own.
+func (c *Compiler) isFunctionName(s Symbol) bool {
+	table := c.symbolTable
+	for s.Scope == FreeScope {
+		s = table.FreeSymbols[s.Index]
+		table = table.Outer
+	}
+
+	return s.Scope == FunctionScope
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case BuiltInScope:
+		c.emit(code.OpConstant, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	case FunctionScope:
+		c.emit(code.OpCurrentClosure)
+	}
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		Constants:    c.constants,
+		GlobalNames:  c.symbolTable.GlobalNames(),
+		Positions:    c.scopes[c.scopeIndex].positions,
+	}
+}
+
+// addConstant returns the index of `obj` among the constants. Equal values share a constant, so that compiling the
+// same literals again, e.g. on every line of the REPL, doesn't run out of constants.
+func (c *Compiler) addConstant(obj object.Object) int {
+	key, ok := internKey(obj)
+	if ok {
+		if index, ok := c.interned[key]; ok {
+			return index
+		}
+	}
+
+	c.constants = append(c.constants, obj)
+	if ok {
+		c.interned[key] = len(c.constants) - 1
+	}
+	return len(c.constants) - 1
+}
+
+// internKey returns the key of a constant that equal values can share, i.e. a value or a built-in function.
+func internKey(obj object.Object) (interface{}, bool) {
+	switch obj := obj.(type) {
+	case *object.BuiltIn:
+		return obj, true
+	case object.Hashable:
+		// the type is part of the key, since a whole float has the hash key of the equal integer
+		return struct {
+			Type object.ObjectType
+			Key  object.HashKey
+		}{obj.Type(), obj.HashKey()}, true
+	}
+
+	return nil, false
+}
+
+// emit appends an instruction to the current scope, and returns its position.
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	ins := c.makeInstruction(op, operands...)
+	pos := c.addInstruction(ins)
+	c.addPosition(pos)
+
+	c.setLastInstruction(op, pos)
+
+	return pos
+}
+
+// makeInstruction encodes an instruction. An operand that doesn't fit in its width, e.g. the index of the 257th local,
+// fails the compilation.
+func (c *Compiler) makeInstruction(op code.Opcode, operands ...int) []byte {
+	def, err := code.Lookup(byte(op))
+	if err == nil {
+		for i, operand := range operands {
+			limit := 1<<(8*def.OperandWidths[i]) - 1
+			if (operand < 0 || operand > limit) && c.err == nil {
+				c.err = fmt.Errorf("operand of %s out of range. limit=`%d`, actual=`%d`", def.Name, limit, operand)
+			}
+		}
+	}
+
+	return code.Make(op, operands...)
+}
+
+// addPosition records that the instruction at `offset` comes from the node being compiled. The instructions after it
+// are gone, e.g. a removed `OpPop`, so their positions are dropped.
+func (c *Compiler) addPosition(offset int) {
+	scope := &c.scopes[c.scopeIndex]
+
+	positions := scope.positions
+	for len(positions) > 0 && positions[len(positions)-1].Offset >= offset {
+		positions = positions[:len(positions)-1]
+	}
+	if len(positions) == 0 || positions[len(positions)-1].Pos != c.pos {
+		positions = append(positions, object.InstructionPosition{Offset: offset, Pos: c.pos})
+	}
+
+	scope.positions = positions
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	posNewInstruction := len(c.currentInstructions())
+	c.scopes[c.scopeIndex].instructions = append(c.currentInstructions(), ins...)
+	return posNewInstruction
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	last := c.scopes[c.scopeIndex].lastInstruction
+	previous := c.scopes[c.scopeIndex].previousInstruction
+
+	c.scopes[c.scopeIndex].instructions = c.currentInstructions()[:last.Position]
+	c.scopes[c.scopeIndex].lastInstruction = previous
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+
+	for i := 0; i < len(newInstruction); i++ {
+		ins[pos+i] = newInstruction[i]
+	}
+}
+
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	newInstruction := c.makeInstruction(op, operand)
+
+	c.replaceInstruction(opPos, newInstruction)
+}
+
+func (c *Compiler) enterScope() {
+	scope := CompilationScope{
+		instructions: code.Instructions{},
+	}
+	c.scopes = append(c.scopes, scope)
+	c.scopeIndex++
+
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() code.Instructions {
+	instructions := c.currentInstructions()
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+
+	c.symbolTable = c.symbolTable.Outer
+
+	return instructions
+}
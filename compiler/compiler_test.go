@@ -0,0 +1,637 @@
+package compiler
+
+import (
+	"fmt"
+	"monkey/code"
+	"monkey/evaluator"
+	"monkey/object"
+	"strings"
+	"testing"
+)
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "1 + 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1.5 * 2",
+			expectedConstants: []interface{}{1.5, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpMul),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1; 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "-1 * 2 <= 3",
+			expectedConstants: []interface{}{1, 2, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpMinus),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpMul),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpLessThanOrEqual),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "!true && false",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpBang),              // 0001
+				code.Make(code.OpJumpNotTruthy, 13), // 0002
+				code.Make(code.OpFalse),             // 0005
+				code.Make(code.OpJumpNotTruthy, 13), // 0006
+				code.Make(code.OpTrue),              // 0009
+				code.Make(code.OpJump, 14),          // 0010
+				code.Make(code.OpFalse),             // 0013
+				code.Make(code.OpPop),               // 0014
+			},
+		},
+		{
+			input:             "false || true",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpFalse),             // 0000
+				code.Make(code.OpBang),              // 0001
+				code.Make(code.OpJumpNotTruthy, 14), // 0002
+				code.Make(code.OpTrue),              // 0005
+				code.Make(code.OpBang),              // 0006
+				code.Make(code.OpJumpNotTruthy, 14), // 0007
+				code.Make(code.OpFalse),             // 0010
+				code.Make(code.OpJump, 15),          // 0011
+				code.Make(code.OpTrue),              // 0014
+				code.Make(code.OpPop),               // 0015
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "if (true) { 10 }; 3333;",
+			expectedConstants: []interface{}{10, 3333},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpJumpNotTruthy, 10), // 0001
+				code.Make(code.OpConstant, 0),       // 0004
+				code.Make(code.OpJump, 11),          // 0007
+				code.Make(code.OpNull),              // 0010
+				code.Make(code.OpPop),               // 0011
+				code.Make(code.OpConstant, 1),       // 0012
+				code.Make(code.OpPop),               // 0015
+			},
+		},
+		{
+			input:             "if (true) { 10 } else { 20 }; 3333;",
+			expectedConstants: []interface{}{10, 20, 3333},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpJumpNotTruthy, 10), // 0001
+				code.Make(code.OpConstant, 0),       // 0004
+				code.Make(code.OpJump, 13),          // 0007
+				code.Make(code.OpConstant, 1),       // 0010
+				code.Make(code.OpPop),               // 0013
+				code.Make(code.OpConstant, 2),       // 0014
+				code.Make(code.OpPop),               // 0017
+			},
+		},
+		{
+			input:             "if (true) { }",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),             // 0000
+				code.Make(code.OpJumpNotTruthy, 8), // 0001
+				code.Make(code.OpNull),             // 0004
+				code.Make(code.OpJump, 9),          // 0005
+				code.Make(code.OpNull),             // 0008
+				code.Make(code.OpPop),              // 0009
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "let x = 1; x = 2;",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAssignGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "let a = [1]; a[0] *= 2;",
+			expectedConstants: []interface{}{1, 0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpUpdateIndex, int(code.OpMul)),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "len = 1", expected: "cannot assign to len"},
+		{input: "let f = fn() { f = 1 };", expected: "cannot assign to f"},
+		{input: "let f = fn() { fn() { f = 1 } };", expected: "cannot assign to f"},
+	}
+
+	for _, tt := range tests {
+		program := parse(tt.input)
+
+		err := New().Compile(program)
+		if err == nil {
+			t.Errorf("no compiler error for input `%s`", tt.input)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong compiler error for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestOperandsOutOfRange(t *testing.T) {
+	var locals, constants strings.Builder
+	for i := 0; i <= 256; i++ {
+		fmt.Fprintf(&locals, "let v%s = 1; ", strings.Repeat("a", i))
+	}
+	for i := 0; i <= 65536; i++ {
+		fmt.Fprintf(&constants, "%d; ", i)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "fn() { " + locals.String() + "}", expected: "operand of OpSetLocal out of range. limit=`255`, actual=`256`"},
+		{input: constants.String(), expected: "operand of OpConstant out of range. limit=`65535`, actual=`65536`"},
+	}
+
+	for _, tt := range tests {
+		err := New().Compile(parse(tt.input))
+		if err == nil {
+			t.Errorf("no compiler error for input of %d bytes", len(tt.input))
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong compiler error. expected=`%s`, actual=`%s`", tt.expected, err)
+		}
+	}
+}
+
+func TestSharedConstants(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `1; 1.0; "a"; 1; "a"`,
+			expectedConstants: []interface{}{1, 1.0, "a"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+
+	// the constants of earlier lines of the REPL are shared too
+	symbolTable := NewSymbolTable()
+	constants := []object.Object{}
+	for _, input := range []string{"1 + 2", "2 + 1"} {
+		compiler := NewWithState(symbolTable, constants)
+		err := compiler.Compile(parse(input))
+		if err != nil {
+			t.Fatalf("compiler error for input `%s`: %s", input, err)
+		}
+		constants = compiler.Bytecode().Constants
+	}
+
+	if len(constants) != 2 {
+		t.Errorf("wrong number of constants across compilations. expected=`2`, actual=`%d`", len(constants))
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "while (true) { break; continue; }; 1;",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpJumpNotTruthy, 13), // 0001
+				code.Make(code.OpJump, 13),          // 0004
+				code.Make(code.OpJump, 0),           // 0007
+				code.Make(code.OpJump, 0),           // 0010
+				code.Make(code.OpConstant, 0),       // 0013
+				code.Make(code.OpPop),               // 0016
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestTryExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "try { 1 } catch (e) { e }; 2;",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTry, 10),      // 0000
+				code.Make(code.OpConstant, 0),  // 0003
+				code.Make(code.OpEndTry),       // 0006
+				code.Make(code.OpJump, 17),     // 0007
+				code.Make(code.OpCatch),        // 0010
+				code.Make(code.OpSetGlobal, 0), // 0011
+				code.Make(code.OpGetGlobal, 0), // 0014
+				code.Make(code.OpPop),          // 0017
+				code.Make(code.OpConstant, 1),  // 0018
+				code.Make(code.OpPop),          // 0021
+			},
+		},
+		{
+			// each copy of the finally block has its own constants
+			input:             "try { 1 } finally { 2 }",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTry, 14),     // 0000
+				code.Make(code.OpConstant, 0), // 0003
+				code.Make(code.OpEndTry),      // 0006
+				code.Make(code.OpConstant, 1), // 0007
+				code.Make(code.OpPop),         // 0010
+				code.Make(code.OpJump, 19),    // 0011
+				code.Make(code.OpConstant, 1), // 0014
+				code.Make(code.OpPop),         // 0017
+				code.Make(code.OpThrow),       // 0018
+				code.Make(code.OpPop),         // 0019
+			},
+		},
+		{
+			input:             "while (true) { try { break } finally { 1 } }",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpJumpNotTruthy, 33), // 0001
+				code.Make(code.OpTry, 24),           // 0004
+				code.Make(code.OpEndTry),            // 0007
+				code.Make(code.OpConstant, 0),       // 0008
+				code.Make(code.OpPop),               // 0011
+				code.Make(code.OpJump, 33),          // 0012
+				code.Make(code.OpNull),              // 0015
+				code.Make(code.OpEndTry),            // 0016
+				code.Make(code.OpConstant, 0),       // 0017
+				code.Make(code.OpPop),               // 0020
+				code.Make(code.OpJump, 29),          // 0021
+				code.Make(code.OpConstant, 0),       // 0024
+				code.Make(code.OpPop),               // 0027
+				code.Make(code.OpThrow),             // 0028
+				code.Make(code.OpPop),               // 0029
+				code.Make(code.OpJump, 0),           // 0030
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestGlobalLetStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "let one = 1; let two = one; two;",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// re-defining a global reuses its slot, and the value still sees the old binding
+			input:             "let a = 1; let a = a + 1;",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpAdd),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+		{
+			// unknown names become globals, which may be defined later
+			input:             "later; let later = 1;",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestCollectionLiterals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `"a ${1} b"`,
+			expectedConstants: []interface{}{"a ", 1, " b"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpInterpolate, 3),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `[1, "two"][0]`,
+			expectedConstants: []interface{}{1, "two", 0},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpArray, 2),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `{1: 2, 3: 4}`,
+			expectedConstants: []interface{}{1, 2, 3, 4},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpHash, 4),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestImports(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `import "lib/math.monkey"; math.pi`,
+			expectedConstants: []interface{}{"lib/math.monkey", "", "pi"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpImport, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpIndex),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	lenBuiltIn, _ := evaluator.LookupBuiltIn("len")
+
+	tests := []compilerTestCase{
+		{
+			input: "fn() { return 5 + 10 }",
+			expectedConstants: []interface{}{
+				5,
+				10,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn() { }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpReturn),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "let add = fn(a, b) { let c = a + b; c }; add(1, 2);",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpSetLocal, 2),
+					code.Make(code.OpGetLocal, 2),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				2,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpCall, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// the default value is set at the start, where a call without `b` enters the function
+			input: "fn(a, b = 2, ...c) { b }",
+			expectedConstants: []interface{}{
+				2,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `len([]); len("")`,
+			expectedConstants: []interface{}{
+				lenBuiltIn,
+				"",
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestClosures(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "fn(a) { fn(b) { a + b } }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpCaptureLocal, 0),
+					code.Make(code.OpClosure, 0, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn(a) { fn() { a += 1 } }",
+			expectedConstants: []interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpSetFree, 0),
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpCaptureLocal, 0),
+					code.Make(code.OpClosure, 1, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "let countDown = fn(x) { countDown(x - 1); };",
+			expectedConstants: []interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpCurrentClosure),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpSub),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+		{
+			input: "let x = 1; fn() { let x = x; x }",
+			expectedConstants: []interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpGetLocalOr, 0, 7),
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpSetLocal, 0),
+					code.Make(code.OpGetLocalOr, 0, 16),
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
@@ -0,0 +1,155 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope   SymbolScope = "GLOBAL"
+	LocalScope    SymbolScope = "LOCAL"
+	FreeScope     SymbolScope = "FREE"
+	FunctionScope SymbolScope = "FUNCTION"
+	BuiltInScope  SymbolScope = "BUILT_IN" // Index is the constant holding the built-in function
+)
+
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store          map[string]Symbol
+	numDefinitions int
+	numParameters  int // the locals below it are parameters, which are bound as soon as the function runs
+
+	FreeSymbols []Symbol          // symbols of the outer scopes used by this scope
+	fallbacks   map[Symbol]Symbol // free symbols of the outer bindings that shadowed variables fall back to
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		store:       make(map[string]Symbol),
+		FreeSymbols: []Symbol{},
+		fallbacks:   make(map[Symbol]Symbol),
+	}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// Define returns the symbol for a new binding. Re-defining a name in the same scope reuses its slot, just like `let` in
+// the evaluator overwrites the binding.
+func (s *SymbolTable) Define(name string) Symbol {
+	symbol := Symbol{Name: name, Index: s.numDefinitions}
+	if s.Outer == nil {
+		symbol.Scope = GlobalScope
+	} else {
+		symbol.Scope = LocalScope
+	}
+
+	if existing, ok := s.store[name]; ok && existing.Scope == symbol.Scope {
+		return existing
+	}
+
+	s.store[name] = symbol
+	s.numDefinitions++
+	return symbol
+}
+
+// DefineFunctionName defines the name a function literal is bound to, so the function can refer to itself.
+func (s *SymbolTable) DefineFunctionName(name string) Symbol {
+	symbol := Symbol{Name: name, Index: 0, Scope: FunctionScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+// DefineGlobal defines `name` in the outermost scope.
+func (s *SymbolTable) DefineGlobal(name string) Symbol {
+	if s.Outer != nil {
+		return s.Outer.DefineGlobal(name)
+	}
+
+	return s.Define(name)
+}
+
+// DefineBuiltIn defines `name` in the outermost scope as the built-in function stored in constant `constantIndex`.
+func (s *SymbolTable) DefineBuiltIn(name string, constantIndex int) Symbol {
+	if s.Outer != nil {
+		return s.Outer.DefineBuiltIn(name, constantIndex)
+	}
+
+	symbol := Symbol{Name: name, Index: constantIndex, Scope: BuiltInScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+// GlobalNames returns the names of the global symbols, indexed by their slot.
+func (s *SymbolTable) GlobalNames() []string {
+	if s.Outer != nil {
+		return s.Outer.GlobalNames()
+	}
+
+	return s.Names()
+}
+
+// Names returns the names of the symbols defined in this scope, indexed by their slot.
+func (s *SymbolTable) Names() []string {
+	names := make([]string, s.numDefinitions)
+	for name, symbol := range s.store {
+		if symbol.Scope == GlobalScope || symbol.Scope == LocalScope {
+			names[symbol.Index] = name
+		}
+	}
+
+	return names
+}
+
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	obj, ok := s.store[name]
+	if !ok && s.Outer != nil {
+		obj, ok = s.Outer.Resolve(name)
+		if !ok {
+			return obj, ok
+		}
+
+		if obj.Scope == GlobalScope || obj.Scope == BuiltInScope {
+			return obj, ok
+		}
+
+		return s.defineFree(obj), true
+	}
+
+	return obj, ok
+}
+
+// capture returns the symbol of `original`, a symbol of an outer scope, for this scope. Unlike `Resolve`, it doesn't
+// bind the name of `original` in this scope, since the name refers to a variable of this scope that shadows it.
+func (s *SymbolTable) capture(original Symbol) Symbol {
+	if original.Scope == GlobalScope || original.Scope == BuiltInScope {
+		return original
+	}
+
+	if symbol, ok := s.fallbacks[original]; ok {
+		return symbol
+	}
+
+	s.FreeSymbols = append(s.FreeSymbols, original)
+	symbol := Symbol{Name: original.Name, Scope: FreeScope, Index: len(s.FreeSymbols) - 1}
+	s.fallbacks[original] = symbol
+
+	return symbol
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Index: len(s.FreeSymbols) - 1}
+	symbol.Scope = FreeScope
+
+	s.store[original.Name] = symbol
+	return symbol
+}
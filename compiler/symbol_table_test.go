@@ -0,0 +1,61 @@
+package compiler
+
+import "testing"
+
+func TestResolveNestedScopes(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	firstLocal := NewEnclosedSymbolTable(global)
+	firstLocal.Define("b")
+
+	secondLocal := NewEnclosedSymbolTable(firstLocal)
+	secondLocal.Define("c")
+
+	expected := []Symbol{
+		{Name: "a", Scope: GlobalScope, Index: 0},
+		{Name: "b", Scope: FreeScope, Index: 0},
+		{Name: "c", Scope: LocalScope, Index: 0},
+	}
+
+	for _, sym := range expected {
+		result, ok := secondLocal.Resolve(sym.Name)
+		if !ok {
+			t.Errorf("name `%s` not resolvable", sym.Name)
+			continue
+		}
+
+		if result != sym {
+			t.Errorf("wrong symbol for `%s`. expected=`%+v`, actual=`%+v`", sym.Name, sym, result)
+		}
+	}
+
+	if len(secondLocal.FreeSymbols) != 1 || secondLocal.FreeSymbols[0] != (Symbol{Name: "b", Scope: LocalScope, Index: 0}) {
+		t.Errorf("wrong free symbols. actual=`%+v`", secondLocal.FreeSymbols)
+	}
+}
+
+func TestDefineReusesSlot(t *testing.T) {
+	global := NewSymbolTable()
+	first := global.Define("a")
+	global.Define("b")
+	second := global.Define("a")
+
+	if first != second {
+		t.Errorf("re-defined symbol has a new slot. expected=`%+v`, actual=`%+v`", first, second)
+	}
+
+	builtIn := global.DefineBuiltIn("len", 3)
+	shadowed := global.Define("len")
+	if shadowed.Scope != GlobalScope || shadowed.Index != 2 {
+		t.Errorf("global does not shadow built-in `%+v`. actual=`%+v`", builtIn, shadowed)
+	}
+
+	names := global.GlobalNames()
+	expectedNames := []string{"a", "b", "len"}
+	for i, name := range expectedNames {
+		if names[i] != name {
+			t.Errorf("wrong global name at %d. expected=`%s`, actual=`%s`", i, name, names[i])
+		}
+	}
+}
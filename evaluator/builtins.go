@@ -1,37 +1,47 @@
 package evaluator
 
-import "monkey/object"
+import (
+	"io"
+	"math"
+	"monkey/object"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
 
 var builtIns = map[string]*object.BuiltIn{
 	"len": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong argument count for `len` function. expected=`1`, actual=`%d`", len(args))
 			}
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(arg.Len())}
 			default:
 				return newError("argument to `len` method is not supported. actual=`%s`", args[0].Type())
 			}
 		},
 	},
 	"first": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong argument count for `first` function. expected=`1`, actual=`%d`", len(args))
 			}
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				str := arg.Value
-				if str == "" {
+				if arg.Value == "" {
 					return Null
 				}
-				return &object.String{Value: string(arg.Value[0])}
+				first, _ := utf8.DecodeRuneInString(arg.Value)
+				return &object.String{Value: string(first)}
 			case *object.Array:
 				elements := arg.Elements
 				if len(elements) <= 0 {
@@ -44,19 +54,18 @@ var builtIns = map[string]*object.BuiltIn{
 		},
 	},
 	"last": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong argument count for `first` function. expected=`1`, actual=`%d`", len(args))
 			}
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				str := arg.Value
-				if str == "" {
+				if arg.Value == "" {
 					return Null
 				}
-				l := len(str)
-				return &object.String{Value: string(arg.Value[l-1])}
+				last, _ := utf8.DecodeLastRuneInString(arg.Value)
+				return &object.String{Value: string(last)}
 			case *object.Array:
 				elements := arg.Elements
 				l := len(elements)
@@ -70,7 +79,7 @@ var builtIns = map[string]*object.BuiltIn{
 		},
 	},
 	"push": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return newError("wrong number of arguments. expected=`2`, actual=`%d`", len(args))
 			}
@@ -94,4 +103,691 @@ var builtIns = map[string]*object.BuiltIn{
 			return &object.Array{Elements: newArr}
 		},
 	},
+	"puts": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			var out strings.Builder
+			for _, arg := range args {
+				out.WriteString(arg.Inspect() + "\n")
+			}
+			return writeOutput(rt, out.String())
+		},
+	},
+	"print": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			strs := make([]string, len(args))
+			for i, arg := range args {
+				strs[i] = arg.Inspect()
+			}
+			return writeOutput(rt, strings.Join(strs, " "))
+		},
+	},
+	"format": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			formatted, err := formatArguments("format", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: formatted}
+		},
+	},
+	"printf": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			formatted, err := formatArguments("printf", args)
+			if err != nil {
+				return err
+			}
+			return writeOutput(rt, formatted)
+		},
+	},
+	"error": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `error` function. expected=`1`, actual=`%d`", len(args))
+			}
+
+			message, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `error` method is not supported. actual=`%s`", args[0].Type())
+			}
+
+			return errorValue(&object.Error{Message: message.Value})
+		},
+	},
+	"keys": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `keys` function. expected=`1`, actual=`%d`", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `keys` method is not supported. actual=`%s`", args[0].Type())
+			}
+
+			keys := []object.Object{}
+			for _, pair := range hash.Pairs() {
+				keys = append(keys, pair.Key)
+			}
+			return &object.Array{Elements: keys}
+		},
+	},
+	"values": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `values` function. expected=`1`, actual=`%d`", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `values` method is not supported. actual=`%s`", args[0].Type())
+			}
+
+			values := []object.Object{}
+			for _, pair := range hash.Pairs() {
+				values = append(values, pair.Value)
+			}
+			return &object.Array{Elements: values}
+		},
+	},
+	"has": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong argument count for `has` function. expected=`2`, actual=`%d`", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("first argument to `has` method is not supported. expected=`%s`, actual=`%s`", object.HashObj, args[0].Type())
+			}
+
+			key, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+
+			_, found := hash.Get(key)
+			return nativeToBooleanObject(found)
+		},
+	},
+	"delete": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong argument count for `delete` function. expected=`2`, actual=`%d`", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("first argument to `delete` method is not supported. expected=`%s`, actual=`%s`", object.HashObj, args[0].Type())
+			}
+
+			key, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+
+			// like `push`, the original hash is left untouched
+			newHash := hash.Copy()
+			newHash.Delete(key)
+			return newHash
+		},
+	},
+	"int": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `int` function. expected=`1`, actual=`%d`", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				return floatToInteger(arg.Value)
+			case *object.String:
+				value, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+				if err != nil {
+					return newError("could not parse %q as integer", arg.Value)
+				}
+				return &object.Integer{Value: value}
+			default:
+				return newError("argument to `int` method is not supported. actual=`%s`", args[0].Type())
+			}
+		},
+	},
+	"float": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `float` function. expected=`1`, actual=`%d`", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return &object.Float{Value: float64(arg.Value)}
+			case *object.Float:
+				return arg
+			case *object.String:
+				value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return newError("could not parse %q as float", arg.Value)
+				}
+				return &object.Float{Value: value}
+			default:
+				return newError("argument to `float` method is not supported. actual=`%s`", args[0].Type())
+			}
+		},
+	},
+	"floor": roundingBuiltIn("floor", math.Floor),
+	"ceil":  roundingBuiltIn("ceil", math.Ceil),
+	"round": roundingBuiltIn("round", math.Round),
+	"split": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			strs, err := stringArguments("split", args, 2)
+			if err != nil {
+				return err
+			}
+
+			parts := strings.Split(strs[0], strs[1])
+			elements := make([]object.Object, len(parts))
+			for i, part := range parts {
+				elements[i] = &object.String{Value: part}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"join": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong argument count for `join` function. expected=`2`, actual=`%d`", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("first argument to `join` method is not supported. expected=`%s`, actual=`%s`", object.ArrayObj, args[0].Type())
+			}
+			sep, ok := args[1].(*object.String)
+			if !ok {
+				return newError("second argument to `join` method is not supported. expected=`%s`, actual=`%s`", object.StringObj, args[1].Type())
+			}
+
+			parts := make([]string, len(arr.Elements))
+			for i, element := range arr.Elements {
+				str, ok := element.(*object.String)
+				if !ok {
+					return newError("elements of the first argument to `join` must be %s. actual=`%s`", object.StringObj, element.Type())
+				}
+				parts[i] = str.Value
+			}
+			return &object.String{Value: strings.Join(parts, sep.Value)}
+		},
+	},
+	"trim": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			strs, err := stringArguments("trim", args, 1)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.TrimSpace(strs[0])}
+		},
+	},
+	"upper": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			strs, err := stringArguments("upper", args, 1)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToUpper(strs[0])}
+		},
+	},
+	"lower": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			strs, err := stringArguments("lower", args, 1)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToLower(strs[0])}
+		},
+	},
+	"contains": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) == 2 && args[0].Type() == object.ArrayObj {
+				return nativeToBooleanObject(elementIndexOf(args[0].(*object.Array), args[1]) >= 0)
+			}
+
+			strs, err := stringArguments("contains", args, 2)
+			if err != nil {
+				return err
+			}
+			return nativeToBooleanObject(strings.Contains(strs[0], strs[1]))
+		},
+	},
+	"replace": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			strs, err := stringArguments("replace", args, 3)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}
+		},
+	},
+	"index_of": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) == 2 && args[0].Type() == object.ArrayObj {
+				return &object.Integer{Value: int64(elementIndexOf(args[0].(*object.Array), args[1]))}
+			}
+
+			strs, err := stringArguments("index_of", args, 2)
+			if err != nil {
+				return err
+			}
+
+			// like string indexing, the index counts characters rather than bytes
+			index := strings.Index(strs[0], strs[1])
+			if index >= 0 {
+				index = utf8.RuneCountInString(strs[0][:index])
+			}
+			return &object.Integer{Value: int64(index)}
+		},
+	},
+	"starts_with": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			strs, err := stringArguments("starts_with", args, 2)
+			if err != nil {
+				return err
+			}
+			return nativeToBooleanObject(strings.HasPrefix(strs[0], strs[1]))
+		},
+	},
+	"ends_with": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			strs, err := stringArguments("ends_with", args, 2)
+			if err != nil {
+				return err
+			}
+			return nativeToBooleanObject(strings.HasSuffix(strs[0], strs[1]))
+		},
+	},
+	"rest": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `rest` function. expected=`1`, actual=`%d`", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				characters := []rune(arg.Value)
+				if len(characters) == 0 {
+					return Null
+				}
+				return &object.String{Value: string(characters[1:])}
+			case *object.Array:
+				if len(arg.Elements) == 0 {
+					return Null
+				}
+				elements := make([]object.Object, len(arg.Elements)-1)
+				copy(elements, arg.Elements[1:])
+				return &object.Array{Elements: elements}
+			default:
+				return newError("argument to `rest` method is not supported. actual=`%s`", args[0].Type())
+			}
+		},
+	},
+	"map": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArguments("map", args)
+			if err != nil {
+				return err
+			}
+
+			elements := make([]object.Object, len(arr.Elements))
+			for i, element := range arr.Elements {
+				elements[i] = applyCallback(rt, fn, element)
+				if isError(elements[i]) {
+					return elements[i]
+				}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"filter": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArguments("filter", args)
+			if err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, element := range arr.Elements {
+				keep := applyCallback(rt, fn, element)
+				if isError(keep) {
+					return keep
+				}
+				if isTruthy(keep) {
+					elements = append(elements, element)
+				}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"reduce": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong argument count for `reduce` function. expected=`2 or 3`, actual=`%d`", len(args))
+			}
+
+			arr, fn, err := arrayAndFunctionArguments("reduce", args[:2])
+			if err != nil {
+				return err
+			}
+
+			// without an initial value, the first element is the initial value
+			elements := arr.Elements
+			var accumulator object.Object = Null
+			if len(args) == 3 {
+				accumulator = args[2]
+			} else if len(elements) > 0 {
+				accumulator = elements[0]
+				elements = elements[1:]
+			}
+
+			for _, element := range elements {
+				accumulator = applyCallback(rt, fn, accumulator, element)
+				if isError(accumulator) {
+					return accumulator
+				}
+			}
+			return accumulator
+		},
+	},
+	"sort": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong argument count for `sort` function. expected=`1 or 2`, actual=`%d`", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("first argument to `sort` method is not supported. expected=`%s`, actual=`%s`", object.ArrayObj, args[0].Type())
+			}
+			if len(args) == 2 && !isFunction(args[1]) {
+				return newError("second argument to `sort` method is not supported. expected=`%s`, actual=`%s`", object.FunctionObj, args[1].Type())
+			}
+
+			// like `push`, the original array is left untouched
+			elements := make([]object.Object, len(arr.Elements))
+			copy(elements, arr.Elements)
+
+			var sortErr object.Object
+			sort.SliceStable(elements, func(i, j int) bool {
+				if sortErr != nil {
+					return false
+				}
+
+				if len(args) == 1 {
+					less, err := lessThan(elements[i], elements[j])
+					if err != nil {
+						sortErr = err
+					}
+					return less
+				}
+
+				// the comparator tells whether its first argument goes before its second one
+				less := applyCallback(rt, args[1], elements[i], elements[j])
+				if isError(less) {
+					sortErr = less
+				}
+				return isTruthy(less)
+			})
+
+			if sortErr != nil {
+				return sortErr
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"reverse": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `reverse` function. expected=`1`, actual=`%d`", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				characters := []rune(arg.Value)
+				for i, j := 0, len(characters)-1; i < j; i, j = i+1, j-1 {
+					characters[i], characters[j] = characters[j], characters[i]
+				}
+				return &object.String{Value: string(characters)}
+			case *object.Array:
+				length := len(arg.Elements)
+				elements := make([]object.Object, length)
+				for i, element := range arg.Elements {
+					elements[length-1-i] = element
+				}
+				return &object.Array{Elements: elements}
+			default:
+				return newError("argument to `reverse` method is not supported. actual=`%s`", args[0].Type())
+			}
+		},
+	},
+	"concat": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			elements := []object.Object{}
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return newError("argument %d to `concat` method is not supported. expected=`%s`, actual=`%s`", i+1, object.ArrayObj, arg.Type())
+				}
+				elements = append(elements, arr.Elements...)
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"range": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong argument count for `range` function. expected=`1 to 3`, actual=`%d`", len(args))
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("%s argument to `range` method is not supported. expected=`%s`, actual=`%s`", ordinals[i], object.IntegerObj, arg.Type())
+				}
+				bounds[i] = integer.Value
+			}
+
+			// range(end), range(start, end) or range(start, end, step)
+			start, end, step := int64(0), bounds[0], int64(1)
+			if len(bounds) > 1 {
+				start, end = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError("step of `range` must not be zero")
+			}
+
+			elements := []object.Object{}
+			for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+				elements = append(elements, &object.Integer{Value: i})
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"zip": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			arrays := make([]*object.Array, len(args))
+			length := -1
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return newError("argument %d to `zip` method is not supported. expected=`%s`, actual=`%s`", i+1, object.ArrayObj, arg.Type())
+				}
+				arrays[i] = arr
+				if length < 0 || len(arr.Elements) < length {
+					length = len(arr.Elements)
+				}
+			}
+
+			// the result is as long as the shortest array
+			elements := []object.Object{}
+			for i := 0; i < length; i++ {
+				tuple := make([]object.Object, len(arrays))
+				for j, arr := range arrays {
+					tuple[j] = arr.Elements[i]
+				}
+				elements = append(elements, &object.Array{Elements: tuple})
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"flatten": {
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `flatten` function. expected=`1`, actual=`%d`", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `flatten` method is not supported. actual=`%s`", args[0].Type())
+			}
+
+			// only one level of nesting is removed
+			elements := []object.Object{}
+			for _, element := range arr.Elements {
+				if nested, ok := element.(*object.Array); ok {
+					elements = append(elements, nested.Elements...)
+				} else {
+					elements = append(elements, element)
+				}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+}
+
+var ordinals = []string{"first", "second", "third"}
+
+// arrayAndFunctionArguments checks that the built-in function `name` is called with an array and a function.
+func arrayAndFunctionArguments(name string, args []object.Object) (*object.Array, object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong argument count for `%s` function. expected=`2`, actual=`%d`", name, len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, nil, newError("first argument to `%s` method is not supported. expected=`%s`, actual=`%s`", name, object.ArrayObj, args[0].Type())
+	}
+	if !isFunction(args[1]) {
+		return nil, nil, newError("second argument to `%s` method is not supported. expected=`%s`, actual=`%s`", name, object.FunctionObj, args[1].Type())
+	}
+
+	return arr, args[1], nil
+}
+
+func isFunction(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Closure, *object.BuiltIn:
+		return true
+	default:
+		return false
+	}
+}
+
+// applyCallback calls a function passed to a built-in function, through the engine that called the built-in function.
+func applyCallback(rt object.Runtime, fn object.Object, args ...object.Object) object.Object {
+	result := rt.ApplyFunction(fn, args)
+	if result == nil {
+		return Null
+	}
+	return result
+}
+
+// lessThan orders numbers and strings for `sort` without a comparator.
+func lessThan(a, b object.Object) (bool, *object.Error) {
+	switch {
+	case a.Type() == object.IntegerObj && b.Type() == object.IntegerObj:
+		return a.(*object.Integer).Value < b.(*object.Integer).Value, nil
+	case isNumber(a) && isNumber(b):
+		return toFloat(a) < toFloat(b), nil
+	case a.Type() == object.StringObj && b.Type() == object.StringObj:
+		return a.(*object.String).Value < b.(*object.String).Value, nil
+	default:
+		return false, newError("cannot sort %s and %s without a comparator", a.Type(), b.Type())
+	}
+}
+
+// stringArguments checks that the built-in function `name` is called with `count` strings, and returns their values.
+func stringArguments(name string, args []object.Object, count int) ([]string, *object.Error) {
+	if len(args) != count {
+		return nil, newError("wrong argument count for `%s` function. expected=`%d`, actual=`%d`", name, count, len(args))
+	}
+
+	strs := make([]string, count)
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok && count == 1 {
+			return nil, newError("argument to `%s` method is not supported. actual=`%s`", name, arg.Type())
+		}
+		if !ok {
+			return nil, newError("%s argument to `%s` method is not supported. expected=`%s`, actual=`%s`", ordinals[i], name, object.StringObj, arg.Type())
+		}
+		strs[i] = str.Value
+	}
+
+	return strs, nil
+}
+
+// roundingBuiltIn creates a built-in that rounds a number to an INTEGER with `round`.
+func roundingBuiltIn(name string, round func(float64) float64) *object.BuiltIn {
+	return &object.BuiltIn{
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong argument count for `%s` function. expected=`1`, actual=`%d`", name, len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				return floatToInteger(round(arg.Value))
+			default:
+				return newError("argument to `%s` method is not supported. actual=`%s`", name, args[0].Type())
+			}
+		},
+	}
+}
+
+// elementIndexOf returns the index of the first element of arr that equals target by `==`, or -1.
+func elementIndexOf(arr *object.Array, target object.Object) int {
+	for i, element := range arr.Elements {
+		if element.Equals(target) {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeOutput writes `str` to the output of the runtime, and returns null like the built-in functions that print.
+func writeOutput(rt object.Runtime, str string) object.Object {
+	_, err := io.WriteString(rt.Output(), str)
+	if err != nil {
+		return newError("cannot write the output: %s", err)
+	}
+	return Null
+}
+
+// formatArguments formats the arguments of `format` and `printf`, which are the format followed by its values.
+func formatArguments(name string, args []object.Object) (string, *object.Error) {
+	if len(args) < 1 {
+		return "", newError("wrong argument count for `%s` function. expected=`at least 1`, actual=`%d`", name, len(args))
+	}
+
+	format, ok := args[0].(*object.String)
+	if !ok {
+		return "", newError("first argument to `%s` method is not supported. expected=`STRING`, actual=`%s`", name, args[0].Type())
+	}
+
+	return formatString(format.Value, args[1:])
 }
@@ -6,4 +6,7 @@ var (
 	True  = &object.Boolean{Value: true}
 	False = &object.Boolean{Value: false}
 	Null  = &object.Null{}
+
+	Break    = &object.Break{}
+	Continue = &object.Continue{}
 )
@@ -2,102 +2,195 @@ package evaluator
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"monkey/ast"
 	"monkey/object"
+	"monkey/token"
+	"strings"
 )
 
+// Evaluator evaluates programs. Its fields configure the semantics, the zero value is the default configuration.
+type Evaluator struct {
+	// CheckedArithmetic turns an integer overflow into an error, instead of wrapping around.
+	CheckedArithmetic bool
+	// Out is where `puts`, `print` and `printf` write. It is os.Stdout when nil.
+	Out io.Writer
+	// ErrOut is where the warnings about imported modules are written. It is os.Stderr when nil.
+	ErrOut io.Writer
+	// Host runs the functions of the engine that uses this evaluator, e.g. the closures of the VM, when they are passed
+	// to the functions of an imported module.
+	Host object.Runtime
+
+	modules modules
+}
+
+func New() *Evaluator {
+	return &Evaluator{}
+}
+
+// Eval evaluates `node` with the default configuration.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New().Eval(node, env)
+}
+
+func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
+	result := e.eval(node, env)
+
+	// the innermost node that produced an error is the best place to point at
+	if errObj, ok := result.(*object.Error); ok && !errObj.Pos.IsValid() {
+		errObj.Pos = node.Pos()
+	}
+
+	return result
+}
+
+func (e *Evaluator) eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalProgram(node, env)
+		return e.evalProgram(node, env)
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := e.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return e.evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
+		if node.Operator == "&&" || node.Operator == "||" {
+			return e.evalLogicalExpression(node, env)
+		}
+
+		left := e.Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
 
-		right := Eval(node.Right, env)
+		right := e.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return e.evalInfixExpression(node.Operator, left, right)
 	case *ast.ReturnStatement:
-		return evalReturnExpression(node, env)
+		return e.evalReturnExpression(node, env)
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return e.evalBlockStatement(node, env)
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return e.evalIfExpression(node, env)
+	case *ast.TryExpression:
+		return e.evalTryExpression(node, env)
+	case *ast.ThrowStatement:
+		return e.evalThrowStatement(node, env)
+	case *ast.WhileStatement:
+		return e.evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return e.evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return Break
+	case *ast.ContinueStatement:
+		return Continue
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return e.Eval(node.Expression, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.BooleanLiteral:
 		return nativeToBooleanObject(node.Value)
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
+		val := e.Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
 
-		env.Set(node.Name.Value, val)
+		bind(env, node.Name, val)
+	case *ast.AssignExpression:
+		return e.evalAssignExpression(node, env)
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return e.evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
 		return &object.Function{
 			Parameters: params,
+			Defaults:   node.Defaults,
+			Rest:       node.Rest,
 			Env:        env,
 			Body:       body,
+			Name:       node.Name,
+			Slots:      node.Slots,
 		}
+	case *ast.MacroLiteral:
+		return newError("macros must be defined by a top-level let statement")
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
+		if isCallTo(node, "quote") {
+			if len(node.Arguments) != 1 {
+				return newError("wrong argument count for `quote` function. expected=`1`, actual=`%d`", len(node.Arguments))
+			}
+			return e.quote(node.Arguments[0], env)
+		}
+
+		function := e.Eval(node.Function, env)
 		if isError(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, env)
+		args := e.evalExpressions(node.Arguments, env)
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
 
-		return applyFunction(function, args)
+		return e.applyFunction(function, args, node.Pos())
 	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
+		elements := e.evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
+	case *ast.InterpolatedString:
+		parts := e.evalExpressions(node.Parts, env)
+		if len(parts) == 1 && isError(parts[0]) {
+			return parts[0]
+		}
+		return interpolate(parts)
+	case *ast.HashLiteral:
+		return e.evalHashLiteral(node, env)
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
+		left := e.Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
 
-		index := Eval(node.Index, env)
+		index := e.Eval(node.Index, env)
 		if isError(index) {
 			return index
 		}
 
 		return evalIndexExpression(left, index)
+	case *ast.MemberExpression:
+		left := e.Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+
+		return evalIndexExpression(left, &object.String{Value: node.Member.Value})
+	case *ast.SliceExpression:
+		return e.evalSliceExpression(node, env)
+	case *ast.ImportExpression:
+		return e.evalImportExpression(node, env)
 	}
 
 	return nil
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+func (e *Evaluator) evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 
+	env.Declare(program.Slots)
+
 	for _, statement := range program.Statements {
-		result = Eval(statement, env)
+		result = e.Eval(statement, env)
 
 		switch r := result.(type) {
 		case *object.ReturnValue:
@@ -110,15 +203,15 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+func (e *Evaluator) evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range block.Statements {
-		result = Eval(statement, env)
+		result = e.Eval(statement, env)
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.ReturnValueObj || rt == object.ErrorObj {
+			if rt == object.ReturnValueObj || rt == object.ErrorObj || rt == object.BreakObj || rt == object.ContinueObj {
 				return result
 			}
 		}
@@ -135,12 +228,12 @@ func nativeToBooleanObject(input bool) *object.Boolean {
 	return False
 }
 
-func evalPrefixExpression(operator string, right object.Object) object.Object {
+func (e *Evaluator) evalPrefixExpression(operator string, right object.Object) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
-		return evalMinusPrefixOperatorExpression(right)
+		return e.evalMinusPrefixOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -159,27 +252,41 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 	}
 }
 
-func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+func (e *Evaluator) evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	if float, ok := right.(*object.Float); ok {
+		return &object.Float{Value: -float.Value}
+	}
 	if right.Type() != object.IntegerObj {
 		return newError("unknown operator: -%s", right.Type())
 	}
 
 	value := right.(*object.Integer).Value
+	if e.CheckedArithmetic && value == math.MinInt64 {
+		return newError("integer overflow: -(%d)", value)
+	}
+
 	return &object.Integer{Value: -value}
 }
 
-func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+func (e *Evaluator) evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.IntegerObj && right.Type() == object.IntegerObj:
-		return evalIntegerInfixExpression(operator, left, right)
+		return e.evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		// at least one side is a float, so the integer side is promoted
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.BooleanObj && right.Type() == object.BooleanObj:
 		return evalBooleanInfixExpression(operator, left, right)
 	case left.Type() == object.StringObj && right.Type() == object.StringObj:
 		return evalStringInfixExpression(operator, left, right)
+	case operator == "*" && left.Type() == object.StringObj && right.Type() == object.IntegerObj:
+		return evalStringRepetition(left, right)
+	case operator == "*" && left.Type() == object.IntegerObj && right.Type() == object.StringObj:
+		return evalStringRepetition(right, left)
 	case operator == "==":
-		return nativeToBooleanObject(left == right)
+		return nativeToBooleanObject(left.Equals(right))
 	case operator == "!=":
-		return nativeToBooleanObject(left != right)
+		return nativeToBooleanObject(!left.Equals(right))
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -187,19 +294,68 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+func (e *Evaluator) evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
+	switch operator {
+	case "+", "-", "*":
+		result, overflow := integerArithmetic(operator, leftVal, rightVal)
+		if overflow && e.CheckedArithmetic {
+			return newError("integer overflow: %d %s %d", leftVal, operator, rightVal)
+		}
+		return &object.Integer{Value: result}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		if e.CheckedArithmetic && leftVal == math.MinInt64 && rightVal == -1 {
+			return newError("integer overflow: %d / %d", leftVal, rightVal)
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "==":
+		return nativeToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeToBooleanObject(leftVal != rightVal)
+	case "<":
+		return nativeToBooleanObject(leftVal < rightVal)
+	case "<=":
+		return nativeToBooleanObject(leftVal <= rightVal)
+	case ">":
+		return nativeToBooleanObject(leftVal > rightVal)
+	case ">=":
+		return nativeToBooleanObject(leftVal >= rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		return &object.Float{Value: leftVal + rightVal}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		return &object.Float{Value: leftVal - rightVal}
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		return &object.Float{Value: leftVal * rightVal}
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
 	case "==":
 		return nativeToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -217,6 +373,43 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	}
 }
 
+// integerArithmetic applies `+`, `-` or `*`, and reports whether the result wrapped around.
+func integerArithmetic(operator string, left, right int64) (int64, bool) {
+	switch operator {
+	case "+":
+		result := left + right
+		return result, (left > 0 && right > 0 && result < 0) || (left < 0 && right < 0 && result >= 0)
+	case "-":
+		result := left - right
+		return result, (right < 0 && result < left) || (right > 0 && result > left)
+	default:
+		result := left * right
+		return result, left != 0 && (result/left != right || (left == -1 && right == math.MinInt64))
+	}
+}
+
+// evalLogicalExpression evaluates `&&` and `||`, which skip their right operand once the left one decides the result.
+func (e *Evaluator) evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := e.Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if node.Operator == "&&" && !isTruthy(left) {
+		return False
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return True
+	}
+
+	right := e.Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+
+	return nativeToBooleanObject(isTruthy(right))
+}
+
 func evalBooleanInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.Boolean).Value
 	rightVal := right.(*object.Boolean).Value
@@ -226,45 +419,158 @@ func evalBooleanInfixExpression(operator string, left object.Object, right objec
 		return nativeToBooleanObject(leftVal == rightVal)
 	case "!=":
 		return nativeToBooleanObject(leftVal != rightVal)
-	case "&&":
-		return nativeToBooleanObject(leftVal && rightVal)
-	case "||":
-		return nativeToBooleanObject(leftVal || rightVal)
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
 func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
-
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
 
-	return &object.String{
-		Value: leftVal + rightVal,
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeToBooleanObject(leftVal != rightVal)
+	case "<":
+		return nativeToBooleanObject(leftVal < rightVal)
+	case "<=":
+		return nativeToBooleanObject(leftVal <= rightVal)
+	case ">":
+		return nativeToBooleanObject(leftVal > rightVal)
+	case ">=":
+		return nativeToBooleanObject(leftVal >= rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalStringRepetition evaluates `str * count`.
+func evalStringRepetition(str object.Object, count object.Object) object.Object {
+	n := count.(*object.Integer).Value
+	if n < 0 {
+		return newError("negative repetition count: %d", n)
 	}
+
+	return &object.String{Value: strings.Repeat(str.(*object.String).Value, int(n))}
 }
 
-func evalIfExpression(node *ast.IfExpression, env *object.Environment) object.Object {
-	cond := Eval(node.Condition, env)
+func (e *Evaluator) evalIfExpression(node *ast.IfExpression, env *object.Environment) object.Object {
+	cond := e.Eval(node.Condition, env)
 	if isError(cond) {
 		return cond
 	}
 	if isTruthy(cond) {
-		return Eval(node.Consequence, env)
+		return e.Eval(node.Consequence, env)
 	} else if node.Alternative != nil {
-		return Eval(node.Alternative, env)
+		return e.Eval(node.Alternative, env)
 	}
 	return Null
 }
 
+func (e *Evaluator) evalWhileStatement(node *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		cond := e.Eval(node.Condition, env)
+		if isError(cond) {
+			return cond
+		}
+
+		if !isTruthy(cond) {
+			return Null
+		}
+
+		if result, done := e.evalLoopBody(node.Body, env); done {
+			return result
+		}
+	}
+}
+
+func (e *Evaluator) evalForStatement(node *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := e.Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	items := iterate(iterable)
+	if isError(items) {
+		return items
+	}
+
+	for _, item := range items.(*object.Array).Elements {
+		bind(env, node.Variable, item)
+
+		if result, done := e.evalLoopBody(node.Body, env); done {
+			return result
+		}
+	}
+
+	return Null
+}
+
+// evalLoopBody runs one iteration of a loop. It reports whether the loop is done, along with the result of the loop.
+func (e *Evaluator) evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	result := e.Eval(body, env)
+	if result == nil {
+		return nil, false
+	}
+
+	switch result.Type() {
+	case object.ReturnValueObj, object.ErrorObj:
+		return result, true
+	case object.BreakObj:
+		return Null, true
+	}
+
+	return nil, false
+}
+
+// iterate returns the items a `for` loop visits: the elements of an array, the characters of a string, or the keys of a
+// hash.
+func iterate(iterable object.Object) object.Object {
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		elements := make([]object.Object, len(iterable.Elements))
+		copy(elements, iterable.Elements)
+		return &object.Array{Elements: elements}
+	case *object.String:
+		characters := []object.Object{}
+		for _, character := range iterable.Value {
+			characters = append(characters, &object.String{Value: string(character)})
+		}
+		return &object.Array{Elements: characters}
+	case *object.Hash:
+		keys := []object.Object{}
+		for _, pair := range iterable.Pairs() {
+			keys = append(keys, pair.Key)
+		}
+		return &object.Array{Elements: keys}
+	default:
+		return newError("not iterable: %s", iterable.Type())
+	}
+}
+
+// interpolate joins the parts of an interpolated string, showing every value the way `Inspect` does.
+func interpolate(parts []object.Object) *object.String {
+	var out strings.Builder
+	for _, part := range parts {
+		out.WriteString(part.Inspect())
+	}
+	return &object.String{Value: out.String()}
+}
+
 func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ArrayObj && index.Type() == object.IntegerObj:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.StringObj && index.Type() == object.IntegerObj:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.HashObj:
+		return evalHashIndexExpression(left, index)
+	case left.Type() == object.ModuleObj && index.Type() == object.StringObj:
+		return evalModuleIndexExpression(left, index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -272,16 +578,153 @@ func evalIndexExpression(left, index object.Object) object.Object {
 
 func evalArrayIndexExpression(left, index object.Object) object.Object {
 	arrayObject := left.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
-
-	if idx < 0 || idx > max {
+	idx, ok := elementIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
+	if !ok {
 		return Null
 	}
 
 	return arrayObject.Elements[idx]
 }
 
+// elementIndex turns an index, which counts from the end when negative, into an index of a sequence of `length`
+// elements. It reports whether the index is in range.
+func elementIndex(index int64, length int) (int, bool) {
+	if index < 0 {
+		index += int64(length)
+	}
+	if index < 0 || index >= int64(length) {
+		return 0, false
+	}
+
+	return int(index), true
+}
+
+// evalStringIndexExpression returns the character at the given code point index, not byte offset.
+func evalStringIndexExpression(left, index object.Object) object.Object {
+	characters := []rune(left.(*object.String).Value)
+	idx, ok := elementIndex(index.(*object.Integer).Value, len(characters))
+	if !ok {
+		return Null
+	}
+
+	return &object.String{Value: string(characters[idx])}
+}
+
+func (e *Evaluator) evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := e.Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	bounds := []object.Object{Null, Null}
+	for i, bound := range []ast.Expression{node.Low, node.High} {
+		if bound == nil {
+			continue
+		}
+		bounds[i] = e.Eval(bound, env)
+		if isError(bounds[i]) {
+			return bounds[i]
+		}
+	}
+
+	return evalSlice(left, bounds[0], bounds[1])
+}
+
+// evalSlice evaluates `left[low:high]`, where a NULL bound stands for a bound that was left out.
+func evalSlice(left, low, high object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.String:
+		characters := []rune(left.Value)
+		from, to, err := sliceBounds(len(characters), low, high)
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: string(characters[from:to])}
+	case *object.Array:
+		from, to, err := sliceBounds(len(left.Elements), low, high)
+		if err != nil {
+			return err
+		}
+		elements := make([]object.Object, to-from)
+		copy(elements, left.Elements[from:to])
+		return &object.Array{Elements: elements}
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+}
+
+// sliceBounds turns the bounds of a slice into indices of a sequence of `length` elements. Negative bounds count
+// from the end, and bounds out of range are clamped, so slicing never fails on the values of the bounds.
+func sliceBounds(length int, low, high object.Object) (int, int, *object.Error) {
+	bounds := []int{0, length}
+	for i, bound := range []object.Object{low, high} {
+		switch bound := bound.(type) {
+		case *object.Null:
+			continue
+		case *object.Integer:
+			value := bound.Value
+			if value < 0 {
+				value += int64(length)
+			}
+			if value < 0 {
+				value = 0
+			}
+			if value > int64(length) {
+				value = int64(length)
+			}
+			bounds[i] = int(value)
+		default:
+			return 0, 0, newError("slice index must be INTEGER. actual=`%s`", bound.Type())
+		}
+	}
+
+	if bounds[0] > bounds[1] {
+		bounds[0] = bounds[1]
+	}
+	return bounds[0], bounds[1], nil
+}
+
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	hashObject := hash.(*object.Hash)
+
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	value, ok := hashObject.Get(key)
+	if !ok {
+		return Null
+	}
+
+	return value
+}
+
+func (e *Evaluator) evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		key := e.Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := e.Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return hash
+}
+
 func isTruthy(condition object.Object) bool {
 	switch condition {
 	case Null:
@@ -294,8 +737,8 @@ func isTruthy(condition object.Object) bool {
 	return true
 }
 
-func evalReturnExpression(node *ast.ReturnStatement, env *object.Environment) object.Object {
-	val := Eval(node.ReturnValue, env)
+func (e *Evaluator) evalReturnExpression(node *ast.ReturnStatement, env *object.Environment) object.Object {
+	val := e.Eval(node.ReturnValue, env)
 	if isError(val) {
 		return val
 	}
@@ -318,8 +761,15 @@ func isError(obj object.Object) bool {
 	return false
 }
 
-func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-	if val, ok := env.Get(node.Value); ok {
+func (e *Evaluator) evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	var val object.Object
+	var ok bool
+	if node.Resolved {
+		val, ok = env.GetAt(node.Depth, node.Slot, node.Value)
+	} else {
+		val, ok = env.Get(node.Value)
+	}
+	if ok {
 		return val
 	}
 
@@ -330,11 +780,119 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 	return newError("identifier not found: " + node.Value)
 }
 
-func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+func (e *Evaluator) evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	// `+=` applies `+`, while plain `=` has no operator
+	operator := strings.TrimSuffix(node.Operator, "=")
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		var current object.Object
+		if operator != "" {
+			current = e.evalIdentifier(target, env)
+			if isError(current) {
+				return current
+			}
+		}
+
+		value := e.Eval(node.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		if operator != "" {
+			value = e.evalInfixExpression(operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		var ok bool
+		if target.Resolved {
+			ok = env.AssignAt(target.Depth, target.Slot, target.Value, value)
+		} else {
+			ok = env.Assign(target.Value, value)
+		}
+		if !ok {
+			return newError("cannot assign to undeclared identifier: %s", target.Value)
+		}
+
+		return value
+	case *ast.IndexExpression:
+		left := e.Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+
+		index := e.Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+
+		value := e.Eval(node.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		return e.evalIndexAssignment(operator, left, index, value)
+	default:
+		return newError("cannot assign to %s", node.Target)
+	}
+}
+
+// evalIndexAssignment stores `value` at `index` of the array or hash `left`. With an operator, the value stored is the
+// current one combined with `value`, e.g. `arr[i] += value`.
+func (e *Evaluator) evalIndexAssignment(operator string, left, index, value object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("index of array must be INTEGER. actual=`%s`", index.Type())
+		}
+
+		i, ok := elementIndex(idx.Value, len(left.Elements))
+		if !ok {
+			return newError("index out of range: %d", idx.Value)
+		}
+
+		if operator != "" {
+			value = e.evalInfixExpression(operator, left.Elements[i], value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		left.Elements[i] = value
+		return value
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+
+		if operator != "" {
+			current, ok := left.Get(key)
+			if !ok {
+				return newError("key not found: %s", index.Inspect())
+			}
+
+			value = e.evalInfixExpression(operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		left.Set(key, value)
+		return value
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+}
+
+func (e *Evaluator) evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
-	for _, e := range exps {
-		evaluated := Eval(e, env)
+	for _, exp := range exps {
+		evaluated := e.Eval(exp, env)
 		if isError(evaluated) {
 			return []object.Object{evaluated}
 		}
@@ -345,27 +903,94 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// applyFunction calls `fn` with `args`. `pos` is the position of the call, which is added to the stack of an error
+// that the call returns.
+func (e *Evaluator) applyFunction(fn object.Object, args []object.Object, pos token.Position) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		if errObj := arityError(fn.DisplayName(), fn.NumRequired(), len(fn.Parameters), fn.Rest != nil, len(args)); errObj != nil {
+			return errObj
+		}
+
+		var evaluated object.Object
+		extendedEnv, errObj := e.extendFunctionEnv(fn, args)
+		if errObj != nil {
+			evaluated = errObj
+		} else {
+			evaluated = unwrapReturnValue(e.Eval(fn.Body, extendedEnv))
+		}
+		if errObj, ok := evaluated.(*object.Error); ok {
+			errObj.Stack = append(errObj.Stack, object.StackFrame{Function: fn.DisplayName(), Pos: pos})
+		}
+		return evaluated
 	case *object.BuiltIn:
-		return fn.Fn(args...)
+		return fn.Fn(e, args...)
+	case *object.Closure:
+		if e.Host == nil {
+			return newError("not a function: %s", fn.Type())
+		}
+		return e.Host.ApplyFunction(fn, args)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnclosedEnvironment(fn.Env)
+// arityError returns the error for calling the function `name` with `numArgs` arguments, or nil if the function
+// accepts that many. It takes `required` to `params` arguments, or any number from `required` on with a rest parameter.
+func arityError(name string, required int, params int, rest bool, numArgs int) *object.Error {
+	if numArgs >= required && (rest || numArgs <= params) {
+		return nil
+	}
+
+	var expected string
+	switch {
+	case rest:
+		expected = fmt.Sprintf("at least %d", required)
+	case required == params:
+		expected = fmt.Sprintf("%d", params)
+	default:
+		expected = fmt.Sprintf("%d to %d", required, params)
+	}
+
+	return newError("wrong argument count for `%s` function. expected=`%s`, actual=`%d`", name, expected, numArgs)
+}
+
+// extendFunctionEnv binds the parameters of `fn` to `args`, which has an accepted number of arguments. The default
+// value of a missing argument is evaluated in the new environment, so it can refer to the parameters before it.
+func (e *Evaluator) extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
+	env := object.NewFunctionEnvironment(fn.Env, fn.Slots)
 
 	for i, param := range fn.Parameters {
-		env.Set(param.Value, args[i])
+		if i < len(args) {
+			bind(env, param, args[i])
+			continue
+		}
+
+		value := e.Eval(fn.Defaults[i], env)
+		if errObj, ok := value.(*object.Error); ok {
+			return nil, errObj
+		}
+		bind(env, param, value)
+	}
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		bind(env, fn.Rest, &object.Array{Elements: rest})
 	}
 
-	return env
+	return env, nil
+}
+
+// bind binds `name` to `val` in `env`, in its slot when the program is resolved.
+func bind(env *object.Environment, name *ast.Identifier, val object.Object) {
+	if name.Resolved {
+		env.SetAt(name.Slot, name.Value, val)
+	} else {
+		env.Set(name.Value, val)
+	}
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/object"
+	"strings"
+	"testing"
+)
+
+var benchmarkPrograms = []struct {
+	name  string
+	input string
+}{
+	{"Fibonacci", `
+let fib = fn(n) { if (n < 2) { return n } fib(n - 1) + fib(n - 2) };
+fib(20)`},
+	{"Closures", `
+let counter = fn() { let count = 0; fn(step) { count += step } };
+let next = counter();
+let total = 0;
+for (i in range(10000)) { total = next(i) }
+total`},
+	{"NestedScopes", `
+let outer = fn(a) { let inner = fn(b) { let innermost = fn(c) { a + b + c }; innermost(b) }; inner(a) };
+let sum = 0;
+for (i in range(10000)) { sum += outer(i) }
+sum`},
+	{"ManyGlobals", manyGlobals(1000)},
+}
+
+// manyGlobals returns a program that binds `n` globals, and then reads the first one many times.
+func manyGlobals(n int) string {
+	var out strings.Builder
+	for i := 0; i < n; i++ {
+		// identifiers have no digits, so the names are spelled with letters: ga, gb, ..., gz, gba, ...
+		name := ""
+		for j := i; ; j /= 26 {
+			name = string(rune('a'+j%26)) + name
+			if j < 26 {
+				break
+			}
+		}
+		fmt.Fprintf(&out, "let g%s = %d;\n", name, i+1)
+	}
+	out.WriteString("let sum = 0;\nfor (i in range(10000)) { sum += ga }\nsum")
+
+	return out.String()
+}
+
+// BenchmarkResolved evaluates programs whose identifiers are resolved to slots by `Resolve`.
+func BenchmarkResolved(b *testing.B) {
+	for _, bm := range benchmarkPrograms {
+		b.Run(bm.name, func(b *testing.B) {
+			program := testParseProgram(bm.input)
+			e := New()
+			e.Resolve(program, object.NewEnvironment())
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e.Eval(program, object.NewEnvironment())
+			}
+		})
+	}
+}
+
+// BenchmarkUnresolved evaluates the same programs with lookups by name, like the REPL.
+func BenchmarkUnresolved(b *testing.B) {
+	for _, bm := range benchmarkPrograms {
+		b.Run(bm.name, func(b *testing.B) {
+			program := testParseProgram(bm.input)
+			e := New()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e.Eval(program, object.NewEnvironment())
+			}
+		})
+	}
+}
@@ -1,7 +1,14 @@
 package evaluator
 
 import (
+	"bytes"
+	"monkey/ast"
+	"monkey/lexer"
 	"monkey/object"
+	"monkey/parser"
+	"monkey/token"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -26,6 +33,10 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{input: "5 / 2", expected: 2}, // handle decimal division later
 		{input: "2 * (5 + 3)", expected: 16},
 		{input: "3 * (3 * 3) - 6", expected: 21},
+		{input: "7 % 3", expected: 1},
+		{input: "-7 % 3", expected: -1},
+		{input: "2 + 7 % 3 * 2", expected: 4},
+		{input: "9223372036854775807 + 1", expected: -9223372036854775808}, // wraps around unless checked
 	}
 
 	for _, tt := range tests {
@@ -195,6 +206,7 @@ func TestErrorHandling(t *testing.T) {
 			return 1;
 		}`, expectedMessage: "unknown operator: BOOLEAN + BOOLEAN"},
 		{input: "foobar;", expectedMessage: "identifier not found: foobar"},
+		{input: `"a ${missing} b"`, expectedMessage: "identifier not found: missing"},
 		{input: `"Hello" - "world"`, expectedMessage: "unknown operator: STRING - STRING"},
 	}
 
@@ -411,6 +423,14 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1,2,3][-1]",
+			3,
+		},
+		{
+			"[1,2,3][-3]",
+			1,
+		},
+		{
+			"[1,2,3][-4]",
 			nil,
 		},
 		{
@@ -453,3 +473,1018 @@ func TestArrayIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+type ErrorPositionTest struct {
+	input           string
+	expectedInspect string
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []ErrorPositionTest{
+		{input: "foobar;", expectedInspect: "ERROR: 1:1: identifier not found: foobar"},
+		{input: "let a = 5;\nlet b = a + true;", expectedInspect: "ERROR: 2:9: type mismatch: INTEGER + BOOLEAN"},
+		{input: "let f = fn(x) {\n  x + missing\n};\nf(1);", expectedInspect: "ERROR: 2:7: identifier not found: missing"},
+		{input: "len(1, 2)", expectedInspect: "ERROR: 1:1: wrong argument count for `len` function. expected=`1`, actual=`2`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. actual=`%T(%#v)`", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Inspect() != tt.expectedInspect {
+			t.Errorf("wrong error. expected=`%s`, actual=`%s`", tt.expectedInspect, errObj.Inspect())
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("wrong type for `evaluated`. expected=`*object.Hash`, actual=`%T` (%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{key: &object.String{Value: "one"}, value: 1},
+		{key: &object.String{Value: "two"}, value: 2},
+		{key: &object.String{Value: "three"}, value: 3},
+		{key: &object.Integer{Value: 4}, value: 4},
+		{key: True, value: 5},
+		{key: False, value: 6},
+	}
+
+	if result.Len() != len(expected) {
+		t.Fatalf("wrong number of pairs. expected=`%d`, actual=`%d`", len(expected), result.Len())
+	}
+
+	for _, e := range expected {
+		value, ok := result.Get(e.key)
+		if !ok {
+			t.Errorf("no pair for key `%s`", e.key.Inspect())
+			continue
+		}
+
+		testIntegerObject(t, value, e.value, e.key.Inspect())
+	}
+
+	expectedInspect := "{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}"
+	if result.Inspect() != expectedInspect {
+		t.Errorf("wrong `result.Inspect()`. expected=`%s`, actual=`%s`", expectedInspect, result.Inspect())
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `{"foo": 5}["foo"]`, expected: 5},
+		{input: `{"foo": 5}["bar"]`, expected: nil},
+		{input: `let key = "foo"; {"foo": 5}[key]`, expected: 5},
+		{input: `{}["foo"]`, expected: nil},
+		{input: `{5: 5}[5]`, expected: 5},
+		{input: `{true: 5}[true]`, expected: 5},
+		{input: `{false: 5}[false]`, expected: 5},
+		{input: `{"name": "x", 1: true}[1] == true`, expected: true},
+		{input: `{"foo": 5}[fn(x) { x }]`, expected: "unusable as hash key: FUNCTION"},
+		{input: `{[1]: 5}`, expected: "unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected), tt.input)
+		case bool:
+			testBooleanObject(t, evaluated, expected, tt.input)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for input `%s`. actual=`%T(%#v)`", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated, tt.input)
+		}
+	}
+}
+
+func TestHashBuiltInFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `keys({"a": 1, "b": 2})`, expected: "[a, b]"},
+		{input: `keys({})`, expected: "[]"},
+		{input: `values({"a": 1, "b": 2})`, expected: "[1, 2]"},
+		{input: `has({"a": 1}, "a")`, expected: true},
+		{input: `has({"a": 1}, "b")`, expected: false},
+		{input: `delete({"a": 1, "b": 2}, "a")`, expected: "{b: 2}"},
+		{input: `let h = {"a": 1, "b": 2}; let d = delete(h, "a"); len(h)`, expected: 2},
+		{input: `len(delete({"a": 1}, "missing"))`, expected: 1},
+		{input: `len({"a": 1, 2: "b"})`, expected: 2},
+		{input: `keys([1])`, expected: "argument to `keys` method is not supported. actual=`ARRAY`"},
+		{input: `has({}, [1])`, expected: "unusable as hash key: ARRAY"},
+		{input: `delete({})`, expected: "wrong argument count for `delete` function. expected=`2`, actual=`1`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected), tt.input)
+		case bool:
+			testBooleanObject(t, evaluated, expected, tt.input)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "let i = 0; while (i < 5) { let i = i + 1; } i", expected: 5},
+		{input: "let i = 0; while (true) { if (i == 3) { break; } let i = i + 1; } i", expected: 3},
+		{input: "let n = 0; let i = 0; while (i < 5) { let i = i + 1; if (i == 2) { continue; } let n = n + i; } n", expected: 13},
+		{input: "let n = 0; for (x in [1, 2, 3]) { let n = n + x; } n", expected: 6},
+		{input: "let n = 0; for (x in [1, 2, 3, 4]) { if (x == 2) { continue; } if (x == 4) { break; } let n = n + x; } n", expected: 4},
+		{input: "let n = 0; for (x in [1, 2]) { for (y in [10, 20]) { if (y == 20) { break; } let n = n + x * y; } } n", expected: 30},
+		{input: `let s = ""; for (c in "abc") { let s = c + s; } s`, expected: "cba"},
+		{input: `let s = ""; for (k in {"a": 1, "b": 2}) { let s = s + k; } s`, expected: "ab"},
+		{input: "let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x; } } 0 }; f()", expected: 2},
+		{input: "while (false) { 1 }", expected: nil},
+		{input: "for (x in 5) { x }", expected: "not iterable: INTEGER"},
+		{input: "while (true) { 1 + true; }", expected: "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected), tt.input)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, evaluated.Inspect())
+			}
+		case nil:
+			testNullObject(t, evaluated, tt.input)
+		}
+	}
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "let x = 1; x = 2; x", expected: 2},
+		{input: "let x = 1; x = x + 1", expected: 2},
+		{input: "let a = 1; let b = 2; a = b = 3; a + b", expected: 6},
+		{input: "let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", expected: 6},
+		{input: `let s = "a"; s += "b"; s`, expected: "ab"},
+		{input: "let newCounter = fn() { let count = 0; fn() { count += 1 } }; let c = newCounter(); c(); c(); c()", expected: 3},
+		{input: "let total = 0; let add = fn(x) { total = total + x; }; add(1); add(2); total", expected: 3},
+		{input: "let x = 1; let f = fn() { let x = 2; x = 3; x }; f() + x", expected: 4},
+		{input: "let n = 0; for (x in [1, 2, 3]) { n += x; } n", expected: 6},
+		{input: "let a = [1, 2, 3]; a[1] = 5; a", expected: "[1, 5, 3]"},
+		{input: "let a = [1, 2, 3]; let b = a; b[0] += 10; a", expected: "[11, 2, 3]"},
+		{input: `let h = {"a": 1}; h["b"] = 2; h["a"] *= 5; h`, expected: "{a: 5, b: 2}"},
+		{input: "let a = [[1]]; a[0][0] = 2; a", expected: "[[2]]"},
+		{input: "let a = [1]; a[0] = 7", expected: 7},
+		{input: "x = 1", expected: "cannot assign to undeclared identifier: x"},
+		{input: "let f = fn() { y = 1 }; f()", expected: "cannot assign to undeclared identifier: y"},
+		{input: "len = 1", expected: "cannot assign to undeclared identifier: len"},
+		{input: "x += 1", expected: "identifier not found: x"},
+		{input: "let x = 1; x += true", expected: "type mismatch: INTEGER + BOOLEAN"},
+		{input: "let a = [1]; a[1] = 2", expected: "index out of range: 1"},
+		{input: "let a = [1, 2]; a[-1] = 5; a[1]", expected: 5},
+		{input: "let a = [1, 2]; a[-2] += 5; a[0]", expected: 6},
+		{input: "let a = [1, 2]; a[-3] = 5", expected: "index out of range: -3"},
+		{input: "let a = [1]; a[true] = 2", expected: "index of array must be INTEGER. actual=`BOOLEAN`"},
+		{input: `let h = {}; h["a"] += 1`, expected: "key not found: a"},
+		{input: `let h = {}; h[[1]] = 1`, expected: "unusable as hash key: ARRAY"},
+		{input: `let s = "abc"; s[0] = "x"`, expected: "index assignment not supported: STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected), tt.input)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestIntegerArithmeticErrors(t *testing.T) {
+	tests := []ErrorTest{
+		{input: "1 / 0", expectedMessage: "division by zero"},
+		{input: "let zero = 0; 5 % zero", expectedMessage: "division by zero"},
+		{input: "let f = fn(x) { 10 / x }; f(0)", expectedMessage: "division by zero"},
+		{input: "let x = 1; x /= 0", expectedMessage: "division by zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for input `%s`. actual=`%T(%#v)`", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "9223372036854775806 + 1", expected: 9223372036854775807},
+		{input: "9223372036854775807 + 1", expected: "integer overflow: 9223372036854775807 + 1"},
+		{input: "-9223372036854775807 - 1", expected: -9223372036854775808},
+		{input: "-9223372036854775807 - 2", expected: "integer overflow: -9223372036854775807 - 2"},
+		{input: "1 - -9223372036854775807", expected: "integer overflow: 1 - -9223372036854775807"},
+		{input: "4611686018427387904 * 2", expected: "integer overflow: 4611686018427387904 * 2"},
+		{input: "-4611686018427387904 * 2", expected: -9223372036854775808},
+		{input: "let min = -9223372036854775807 - 1; min * -1", expected: "integer overflow: -9223372036854775808 * -1"},
+		{input: "let min = -9223372036854775807 - 1; -1 * min", expected: "integer overflow: -1 * -9223372036854775808"},
+		{input: "let min = -9223372036854775807 - 1; min / -1", expected: "integer overflow: -9223372036854775808 / -1"},
+		{input: "let min = -9223372036854775807 - 1; -min", expected: "integer overflow: -(-9223372036854775808)"},
+		{input: "let min = -9223372036854775807 - 1; min % -1", expected: 0},
+		{input: "let x = 9223372036854775807; x += 1", expected: "integer overflow: 9223372036854775807 + 1"},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		evaluator := New()
+		evaluator.CheckedArithmetic = true
+		evaluated := evaluator.Eval(program, object.NewEnvironment())
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected), tt.input)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for input `%s`. actual=`%T(%#v)`", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestFloats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "3.14", expected: 3.14},
+		{input: "1e-9", expected: 1e-9},
+		{input: "-2.5", expected: -2.5},
+		{input: "1.5 + 1.5", expected: 3.0},
+		{input: "1 + 0.5", expected: 1.5},
+		{input: "0.5 * 4", expected: 2.0},
+		{input: "7 / 2.0", expected: 3.5},
+		{input: "7.5 % 2", expected: 1.5},
+		{input: "let x = 1; x += 0.25; x", expected: 1.25},
+		{input: "1.5 < 2", expected: true},
+		{input: "2 >= 2.5", expected: false},
+		{input: "1 == 1.0", expected: true},
+		{input: "0.1 + 0.2 != 0.3", expected: true},
+		{input: "1.0 / 0", expected: "division by zero"},
+		{input: "1 % 0.0", expected: "division by zero"},
+		{input: "1.5 + true", expected: "type mismatch: FLOAT + BOOLEAN"},
+
+		{input: "int(3.99)", expected: 3},
+		{input: "int(-3.99)", expected: -3},
+		{input: `int(" 42 ")`, expected: 42},
+		{input: `int("4.2")`, expected: `could not parse "4.2" as integer`},
+		{input: "int(1e19)", expected: "cannot convert 1e+19 to INTEGER"},
+		{input: "int(true)", expected: "argument to `int` method is not supported. actual=`BOOLEAN`"},
+		{input: "float(2)", expected: 2.0},
+		{input: `float("1e3")`, expected: 1000.0},
+		{input: `float("abc")`, expected: `could not parse "abc" as float`},
+		{input: "float(1, 2)", expected: "wrong argument count for `float` function. expected=`1`, actual=`2`"},
+		{input: "floor(2.7)", expected: 2},
+		{input: "floor(-2.2)", expected: -3},
+		{input: "ceil(2.2)", expected: 3},
+		{input: "ceil(5)", expected: 5},
+		{input: "round(2.5)", expected: 3},
+		{input: "round(-2.5)", expected: -3},
+		{input: "round(2.49)", expected: 2},
+		{input: `round("2")`, expected: "argument to `round` method is not supported. actual=`STRING`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected), tt.input)
+		case float64:
+			testFloatObject(t, evaluated, expected, tt.input)
+		case bool:
+			testBooleanObject(t, evaluated, expected, tt.input)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for input `%s`. actual=`%T(%#v)`", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestUnicodeStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `len("héllo")`, expected: 5},
+		{input: `len("世界")`, expected: 2},
+		{input: `first("ünicode")`, expected: "ü"},
+		{input: `last("smile 😀")`, expected: "😀"},
+		{input: `"日本語"[0]`, expected: "日"},
+		{input: `"日本語"[2]`, expected: "語"},
+		{input: `"日本語"[3]`, expected: nil},
+		{input: `"日本語"[-1]`, expected: "語"},
+		{input: `"日本語"[-4]`, expected: nil},
+		{input: `let größe = "groß"; größe[3]`, expected: "ß"},
+		{input: `let s = ""; for (c in "añb") { s += c + "," }; s`, expected: "a,ñ,b,"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected), tt.input)
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("wrong obj type for input `%s`. expected=`*object.String`, actual=`%T(%+v)`", tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("wrong value for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, str.Value)
+			}
+		case nil:
+			testNullObject(t, evaluated, tt.input)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `let name = "Ada"; let age = 36; "hello ${name}, you are ${age + 1}"`, expected: "hello Ada, you are 37"},
+		{input: `"${1.5} ${true} ${[1, "a"]} ${{"k": 2}} ${if (false) { 1 }}"`, expected: "1.5 true [1, a] {k: 2} null"},
+		{input: `"${"nested ${1 + 1}"}!"`, expected: "nested 2!"},
+		{input: `let f = fn(x) { "<${x}>" }; f(f(1))`, expected: "<<1>>"},
+		{input: `"\${x} costs $5"`, expected: "${x} costs $5"},
+		{input: "`raw ${x}`", expected: "raw ${x}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("wrong obj type for input `%s`. expected=`*object.String`, actual=`%T(%+v)`", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("wrong value for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expected, str.Value)
+		}
+	}
+}
+
+func TestStringOperations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `"a" == "a"`, expected: true},
+		{input: `let a = "x"; let b = "x"; a == b`, expected: true},
+		{input: `"a" != "b"`, expected: true},
+		{input: `"abc" < "abd"`, expected: true},
+		{input: `"b" > "abc"`, expected: true},
+		{input: `"a" >= "a"`, expected: true},
+		{input: `"ab" * 3`, expected: "ababab"},
+		{input: `2 * "-"`, expected: "--"},
+		{input: `"x" * 0`, expected: ""},
+		{input: `let s = "na"; s *= 2; s`, expected: "nana"},
+		{input: `"hello"[1]`, expected: "e"},
+		{input: `"hello"[1:3]`, expected: "el"},
+		{input: `"hello"[:2]`, expected: "he"},
+		{input: `"hello"[3:]`, expected: "lo"},
+		{input: `"hello"[:]`, expected: "hello"},
+		{input: `"hello"[-3:-1]`, expected: "ll"},
+		{input: `"hello"[2:100]`, expected: "llo"},
+		{input: `"hello"[4:1]`, expected: ""},
+		{input: `"日本語です"[1:3]`, expected: "本語"},
+
+		// errors
+		{input: `"a" * -1`, expected: errorMessage("negative repetition count: -1")},
+		{input: `"a" - "b"`, expected: errorMessage("unknown operator: STRING - STRING")},
+		{input: `"abc"["a":]`, expected: errorMessage("slice index must be INTEGER. actual=`STRING`")},
+		{input: `5[1:2]`, expected: errorMessage("slice operator not supported: INTEGER")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestStringBuiltInFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `split("a,b,,c", ",")`, expected: []string{"a", "b", "", "c"}},
+		{input: `split("añb", "")`, expected: []string{"a", "ñ", "b"}},
+		{input: `join(["a", "b", "c"], ", ")`, expected: "a, b, c"},
+		{input: `join([], "-")`, expected: ""},
+		{input: `trim("  hi \n")`, expected: "hi"},
+		{input: `upper("ünïcode")`, expected: "ÜNÏCODE"},
+		{input: `lower("ÀBC")`, expected: "àbc"},
+		{input: `contains("monkey", "key")`, expected: true},
+		{input: `contains("monkey", "dog")`, expected: false},
+		{input: `replace("a-b-c", "-", "+")`, expected: "a+b+c"},
+		{input: `index_of("hello", "l")`, expected: 2},
+		{input: `index_of("日本語", "語")`, expected: 2},
+		{input: `index_of("hello", "z")`, expected: -1},
+		{input: `starts_with("monkey", "mon")`, expected: true},
+		{input: `ends_with("monkey", "mon")`, expected: false},
+
+		// errors
+		{input: `split("a")`, expected: errorMessage("wrong argument count for `split` function. expected=`2`, actual=`1`")},
+		{input: `upper(1)`, expected: errorMessage("argument to `upper` method is not supported. actual=`INTEGER`")},
+		{input: `replace("a", "b", 3)`, expected: errorMessage("third argument to `replace` method is not supported. expected=`STRING`, actual=`INTEGER`")},
+		{input: `join("abc", "")`, expected: errorMessage("first argument to `join` method is not supported. expected=`ARRAY`, actual=`STRING`")},
+		{input: `join(["a", 1], "")`, expected: errorMessage("elements of the first argument to `join` must be STRING. actual=`INTEGER`")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestArraySlices(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "[1, 2, 3, 4][1:3]", expected: []int{2, 3}},
+		{input: "[1, 2, 3, 4][:2]", expected: []int{1, 2}},
+		{input: "[1, 2, 3, 4][2:]", expected: []int{3, 4}},
+		{input: "[1, 2, 3, 4][-2:]", expected: []int{3, 4}},
+		{input: "[1, 2, 3, 4][:-1]", expected: []int{1, 2, 3}},
+		{input: "[1, 2, 3, 4][3:1]", expected: []int{}},
+		{input: "[1, 2, 3][-10:10]", expected: []int{1, 2, 3}},
+		{input: "let a = [1, 2, 3]; let b = a[:]; b[0] = 9; a[0]", expected: 1},
+		{input: "[1, 2, 3][1:true]", expected: errorMessage("slice index must be INTEGER. actual=`BOOLEAN`")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestArrayBuiltInFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "rest([1, 2, 3])", expected: []int{2, 3}},
+		{input: "rest([])", expected: nil},
+		{input: `rest("añb")`, expected: "ñb"},
+		{input: "map([1, 2, 3], fn(x) { x * 2 })", expected: []int{2, 4, 6}},
+		{input: "let n = 10; map([1, 2], fn(x) { x + n })", expected: []int{11, 12}},
+		{input: `map(["a", "b"], upper)`, expected: []string{"A", "B"}},
+		{input: "map([[1, 2], [3]], fn(xs) { map(xs, fn(x) { x * 10 }) })[0]", expected: []int{10, 20}},
+		{input: "filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })", expected: []int{2, 4}},
+		{input: "reduce([1, 2, 3, 4], fn(acc, x) { acc + x })", expected: 10},
+		{input: "reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)", expected: 16},
+		{input: "reduce([], fn(acc, x) { acc + x })", expected: nil},
+		{input: "reduce([], fn(acc, x) { acc + x }, 0)", expected: 0},
+		{input: "sort([3, 1, 2])", expected: []int{1, 2, 3}},
+		{input: `sort(["b", "c", "a"])`, expected: []string{"a", "b", "c"}},
+		{input: "sort([3, 1, 2], fn(a, b) { a > b })", expected: []int{3, 2, 1}},
+		{input: "let a = [2, 1]; sort(a); a", expected: []int{2, 1}},
+		{input: "sort([2.5, 1, 2])[0]", expected: 1},
+		{input: "reverse([1, 2, 3])", expected: []int{3, 2, 1}},
+		{input: `reverse("añb")`, expected: "bña"},
+		{input: "concat([1], [], [2, 3])", expected: []int{1, 2, 3}},
+		{input: "concat()", expected: []int{}},
+		{input: "range(4)", expected: []int{0, 1, 2, 3}},
+		{input: "range(2, 5)", expected: []int{2, 3, 4}},
+		{input: "range(5, 0, -2)", expected: []int{5, 3, 1}},
+		{input: "range(3, 1)", expected: []int{}},
+		{input: "zip([1, 2, 3], [4, 5])[1]", expected: []int{2, 5}},
+		{input: "len(zip([1, 2, 3], [4, 5]))", expected: 2},
+		{input: "flatten([1, [2, 3], [], [[4]]])[3]", expected: []int{4}},
+		{input: "len(flatten([1, [2, 3], [], [[4]]]))", expected: 4},
+
+		// errors
+		{input: "map([1], 2)", expected: errorMessage("second argument to `map` method is not supported. expected=`FUNCTION`, actual=`INTEGER`")},
+		{input: "filter(1, fn(x) { x })", expected: errorMessage("first argument to `filter` method is not supported. expected=`ARRAY`, actual=`INTEGER`")},
+		{input: "map([1, 0], fn(x) { 1 / x })", expected: errorMessage("division by zero")},
+		{input: "reduce([1])", expected: errorMessage("wrong argument count for `reduce` function. expected=`2 or 3`, actual=`1`")},
+		{input: `sort([1, "a"])`, expected: errorMessage("cannot sort STRING and INTEGER without a comparator")},
+		{input: "sort([1, 2], fn(a, b) { a + true })", expected: errorMessage("type mismatch: INTEGER + BOOLEAN")},
+		{input: "range(1, 2, 0)", expected: errorMessage("step of `range` must not be zero")},
+		{input: `range("3")`, expected: errorMessage("first argument to `range` method is not supported. expected=`INTEGER`, actual=`STRING`")},
+		{input: "concat([1], 2)", expected: errorMessage("argument 2 to `concat` method is not supported. expected=`ARRAY`, actual=`INTEGER`")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestShortCircuitEvaluation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "false && crash()", expected: false},
+		{input: "true || crash()", expected: true},
+		{input: "let a = []; len(a) > 0 && first(a) == 1", expected: false},
+		{input: "let a = [1]; len(a) > 0 && first(a) == 1", expected: true},
+		{input: "let calls = 0; let f = fn() { calls += 1; true }; false && f(); true || f(); calls", expected: 0},
+		{input: "let calls = 0; let f = fn() { calls += 1; true }; true && f(); false || f(); calls", expected: 2},
+		{input: `1 && "a"`, expected: true},
+		{input: "0 || false", expected: true},
+		{input: "if (false) { 1 } || [] && null_value", expected: errorMessage("identifier not found: null_value")},
+		{input: "true && crash()", expected: errorMessage("identifier not found: crash")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "[1, 2] == [1, 2]", expected: true},
+		{input: "[1, 2] == [2, 1]", expected: false},
+		{input: "[1, 2] != [1, 2, 3]", expected: true},
+		{input: "[[1, [2]], \"a\"] == [[1, [2]], \"a\"]", expected: true},
+		{input: "[] == []", expected: true},
+		{input: "[1] == [1.0]", expected: true},
+		{input: "let a = [1]; a == a", expected: true},
+		{input: "let a = [1]; a[0] = a; let b = [1]; b[0] = b; a == b", expected: true},
+		{input: "let a = [1, 1]; a[0] = a; let b = [1, 2]; b[0] = b; a == b", expected: false},
+		{input: `let h = {}; h["self"] = h; let g = {}; g["self"] = g; h == g`, expected: true},
+		{input: `{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, expected: true},
+		{input: `{"a": 1} == {"a": 2}`, expected: false},
+		{input: `{"a": 1} == {"b": 1}`, expected: false},
+		{input: `{1: true} == {1.0: true}`, expected: true},
+		{input: `[][0] == {}["a"]`, expected: true},
+		{input: "if (false) { 1 } == [][0]", expected: true},
+		{input: "[][0] != false", expected: true},
+		{input: `1 == "1"`, expected: false},
+		{input: "0 == false", expected: false},
+		{input: "[] == {}", expected: false},
+		{input: "let f = fn() { 1 }; f == f", expected: true},
+		{input: "fn() { 1 } == fn() { 1 }", expected: false},
+		{input: "len == len", expected: true},
+		{input: `{2.0: "a"}[2]`, expected: "a"},
+		{input: `contains([1, [2], "a"], [2])`, expected: true},
+		{input: `contains([1, 2], 3)`, expected: false},
+		{input: `index_of([1, 2, [3]], [3])`, expected: 2},
+		{input: `index_of([1, 2], 2.0)`, expected: 1},
+		{input: `index_of([], 1)`, expected: -1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestErrorStackTraces(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedStack []object.StackFrame
+	}{
+		{input: "1 / 0", expectedStack: nil},
+		{input: "len(1)", expectedStack: nil},
+		{
+			input: "let f = fn() { 1 / 0 }; f()",
+			expectedStack: []object.StackFrame{
+				{Function: "f", Pos: token.Position{Line: 1, Column: 25}},
+			},
+		},
+		{
+			input: "let inner = fn(x) { x + true };\nlet outer = fn() { inner(1) };\n\nouter()",
+			expectedStack: []object.StackFrame{
+				{Function: "inner", Pos: token.Position{Line: 2, Column: 20}},
+				{Function: "outer", Pos: token.Position{Line: 4, Column: 1}},
+			},
+		},
+		{
+			input: "fn() { missing }()",
+			expectedStack: []object.StackFrame{
+				{Function: "<anonymous>", Pos: token.Position{Line: 1, Column: 1}},
+			},
+		},
+		{
+			input: "let f = fn(n) { if (n == 0) { return 1 / 0 } f(n - 1) }; f(2)",
+			expectedStack: []object.StackFrame{
+				{Function: "f", Pos: token.Position{Line: 1, Column: 46}},
+				{Function: "f", Pos: token.Position{Line: 1, Column: 46}},
+				{Function: "f", Pos: token.Position{Line: 1, Column: 58}},
+			},
+		},
+		{
+			input: "let f = fn(x) { x + true }; map([1], f)",
+			expectedStack: []object.StackFrame{
+				{Function: "f"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for input `%s`. actual=`%T(%+v)`", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if len(errObj.Stack) != len(tt.expectedStack) {
+			t.Errorf("wrong stack length for input `%s`. expected=`%d`, actual=`%d` (%+v)", tt.input, len(tt.expectedStack), len(errObj.Stack), errObj.Stack)
+			continue
+		}
+
+		for i, frame := range errObj.Stack {
+			expected := tt.expectedStack[i]
+			if frame.Function != expected.Function || frame.Pos.Line != expected.Pos.Line || frame.Pos.Column != expected.Pos.Column {
+				t.Errorf("wrong frame %d for input `%s`. expected=`%+v`, actual=`%+v`", i, tt.input, expected, frame)
+			}
+		}
+	}
+}
+
+func TestTryCatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `try { 1 } catch (e) { 2 }`, expected: 1},
+		{input: `try { 1 / 0 } catch (e) { e["message"] }`, expected: "division by zero"},
+		{input: `try { throw "oops" } catch (e) { e["message"] }`, expected: "oops"},
+		{input: `try { throw error("oops") } catch (e) { e["message"] }`, expected: "oops"},
+		{input: `try { throw 1 } catch (e) { e["message"] }`, expected: "cannot throw INTEGER, only a STRING or an error value"},
+		{input: `let f = fn() { throw "a" }; let g = fn() { f() }; try { g() } catch (e) { e["stack"] }`, expected: []string{"f (called at 1:44)", "g (called at 1:57)"}},
+		{input: `error("oops")["message"]`, expected: "oops"},
+		{input: `error("oops")["stack"]`, expected: []int{}},
+		{input: `throw "oops"`, expected: errorMessage("oops")},
+		{input: `try { throw "a" } catch (e) { throw e }`, expected: errorMessage("a")},
+		{input: `try { throw "a" } catch (e) { }`, expected: nil},
+		{input: `1 + try { throw "a" } catch (e) { 2 }`, expected: 3},
+		{input: `let n = 0; try { n = 1 } finally { n = 2 }; n`, expected: 2},
+		{input: `let n = 0; let r = try { throw "a" } catch (e) { n += 1; 5 } finally { n += 10 }; [n, r]`, expected: []int{11, 5}},
+		{input: `let n = 0; try { try { throw "a" } finally { n += 1 } } catch (e) { n += 10 }; n`, expected: 11},
+		{input: `try { 1 } finally { throw "f" }`, expected: errorMessage("f")},
+		{input: `let f = fn() { try { return 1 } finally { 2 } }; f()`, expected: 1},
+		{input: `let f = fn() { try { return 1 } finally { return 2 } }; f()`, expected: 2},
+		{input: `let n = 0; for (x in [1, 2, 3]) { try { if (x == 2) { break } n += x } finally { n += 10 } }; n`, expected: 21},
+		{input: `try { map([1, 2], fn(x) { if (x == 2) { throw "two" } x }) } catch (e) { e["message"] }`, expected: "two"},
+		{input: `error(1)`, expected: errorMessage("argument to `error` method is not supported. actual=`INTEGER`")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "let f = fn(a, b) { a + b }; f(1)", expected: errorMessage("wrong argument count for `f` function. expected=`2`, actual=`1`")},
+		{input: "fn(a) { a }(1, 2)", expected: errorMessage("wrong argument count for `<anonymous>` function. expected=`1`, actual=`2`")},
+		{input: "let add = fn(a, b = 10) { a + b }; add(1)", expected: 11},
+		{input: "let add = fn(a, b = 10) { a + b }; add(1, 2)", expected: 3},
+		{input: "let add = fn(a, b = 10) { a + b }; add()", expected: errorMessage("wrong argument count for `add` function. expected=`1 to 2`, actual=`0`")},
+		{input: "let f = fn(a, b = a * 2, c = a + b) { [a, b, c] }; f(1)", expected: []int{1, 2, 3}},
+		{input: "let f = fn(a, b = a * 2, c = a + b) { [a, b, c] }; f(1, 5)", expected: []int{1, 5, 6}},
+		{input: "let calls = 0; let f = fn(a = calls += 1) { a }; f(); f(); f(10); calls", expected: 2},
+		{input: "let f = fn(a, ...rest) { rest }; f(1, 2, 3)", expected: []int{2, 3}},
+		{input: "let f = fn(a, ...rest) { rest }; f(1)", expected: []int{}},
+		{input: "let f = fn(a, ...rest) { a }; f()", expected: errorMessage("wrong argument count for `f` function. expected=`at least 1`, actual=`0`")},
+		{input: "let f = fn(a, b = 2, ...rest) { concat([a, b], rest) }; f(1)", expected: []int{1, 2}},
+		{input: "let f = fn(a, b = 2, ...rest) { concat([a, b], rest) }; f(1, 3, 4, 5)", expected: []int{1, 3, 4, 5}},
+		{input: "let f = fn(a = 1 / 0) { a }; f()", expected: errorMessage("division by zero")},
+		{input: "let f = fn(a = 1) { a }; f(5)", expected: 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `format("plain")`, expected: "plain"},
+		{input: `format("%d + %d = %d", 1, 2, 3)`, expected: "1 + 2 = 3"},
+		{input: `format("[%5d] [%-5d] [%05d]", 42, 42, -42)`, expected: "[   42] [42   ] [-0042]"},
+		{input: `format("%.2f %f %6.1f", 3.14159, 2, 2.25)`, expected: "3.14 2.000000    2.2"},
+		{input: `format("%s and %v", "text", [1, "a", [2]])`, expected: "text and [1, a, [2]]"},
+		{input: `format("[%4s] [%-4s]", "ab", "日本")`, expected: "[  ab] [日本  ]"},
+		{input: `format("%q", "say \"hi\"")`, expected: `"say \"hi\""`},
+		{input: `format("100%%")`, expected: "100%"},
+		{input: `format("%d", "1")`, expected: errorMessage("argument for `%d` is not supported. expected=`INTEGER`, actual=`STRING`")},
+		{input: `format("%d %d", 1)`, expected: errorMessage("missing argument for `%d` in format")},
+		{input: `format("%d", 1, 2)`, expected: errorMessage("too many arguments for format. expected=`1`, actual=`2`")},
+		{input: `format("%z", 1)`, expected: errorMessage("unknown verb `%z` in format")},
+		{input: `format("50%")`, expected: errorMessage("incomplete verb `%` at the end of the format")},
+		{input: `format(1)`, expected: errorMessage("first argument to `format` method is not supported. expected=`STRING`, actual=`INTEGER`")},
+		{input: `format()`, expected: errorMessage("wrong argument count for `format` function. expected=`at least 1`, actual=`0`")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{input: `puts("hello")`, expectedOutput: "hello\n"},
+		{input: `puts(1, [1, "a"], {"k": true})`, expectedOutput: "1\n[1, a]\n{k: true}\n"},
+		{input: `puts()`, expectedOutput: ""},
+		{input: `print("a", 1); print("b")`, expectedOutput: "a 1b"},
+		{input: `printf("%s=%03d\n", "x", 5)`, expectedOutput: "x=005\n"},
+		{input: `printf("%d", "x"); puts("unreachable")`, expectedOutput: ""},
+		{input: `map([1, 2], fn(x) { puts(x * 10) })`, expectedOutput: "10\n20\n"},
+		{input: `let a = [1]; a[0] = a; puts(a)`, expectedOutput: "[[...]]\n"},
+		{input: `let h = {"n": 1}; h["self"] = h; puts(h, [h, h])`, expectedOutput: "{n: 1, self: {...}}\n[{n: 1, self: {...}}, {n: 1, self: {...}}]\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+
+		l := lexer.NewLexer(tt.input)
+		p := parser.NewParser(l)
+		e := New()
+		e.Out = &out
+		e.Eval(p.ParseProgram(), object.NewEnvironment())
+
+		if out.String() != tt.expectedOutput {
+			t.Errorf("wrong output for input `%s`. expected=`%q`, actual=`%q`", tt.input, tt.expectedOutput, out.String())
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	libDir := t.TempDir()
+	t.Setenv("MONKEYPATH", libDir)
+
+	testWriteFiles(t, dir, map[string]string{
+		"lib/math.monkey":   `puts("loading math"); let add = fn(a, b) { a + b }; let pi = 3; let _secret = 42;`,
+		"a.monkey":          `let b = import "b.monkey"; let x = 1;`,
+		"b.monkey":          `let a = import "a.monkey";`,
+		"broken.monkey":     `let x = ;`,
+		"failing.monkey":    `let x = 1 / 0;`,
+		"lib/nested.monkey": `import "../lib/math"; let double = fn(x) { math.add(x, x) };`,
+		"undeclared.monkey": `let f = fn() { missing() };`,
+		"unused.monkey":     `let f = fn() { let unused = 1; 2 };`,
+	})
+	testWriteFiles(t, libDir, map[string]string{
+		"strings.monkey": `let shout = fn(s) { s + "!" };`,
+	})
+
+	tests := []struct {
+		input          string
+		expected       interface{}
+		expectedErrOut string
+	}{
+		{input: `import "lib/math.monkey"; math.add(1, 2)`, expected: 3},
+		{input: `let m = import("lib/math"); m["pi"]`, expected: 3},
+		{input: `import("lib/math").add(2, 3)`, expected: 5},
+		{input: `let m = import "lib/math"; import "lib/math.monkey"; m == math`, expected: true},
+		{input: `import "lib/nested"; nested.double(4)`, expected: 8},
+		{input: `import "strings"; strings.shout("hi")`, expected: "hi!"},
+		{input: `import "lib/math"; math._secret`, expected: errorMessage("export not found: math._secret")},
+		{input: `import "lib/math"; math[1]`, expected: errorMessage("index operator not supported: MODULE")},
+		{input: `import "missing"`, expected: errorMessage("module not found: missing")},
+		{input: `import 1`, expected: errorMessage("import path must be STRING. actual=`INTEGER`")},
+		{input: `import "failing"`, expected: errorMessage("division by zero")},
+		{input: `let h = {"a": {"b": 2}}; h.a.b`, expected: 2},
+		{input: `let h = {"a": 1}; h.b`, expected: nil},
+		{
+			input:    `import "a"`,
+			expected: errorMessage("import cycle: " + filepath.Join(dir, "a.monkey") + " -> " + filepath.Join(dir, "b.monkey") + " -> " + filepath.Join(dir, "a.monkey")),
+		},
+		{
+			input:    `import "broken"`,
+			expected: errorMessage("cannot parse module broken:\n\t" + filepath.Join(dir, "broken.monkey") + ":1:9: no prefix parse function for ; found"),
+		},
+		{
+			input:    `import "undeclared"`,
+			expected: errorMessage("cannot resolve module undeclared:\n\t" + filepath.Join(dir, "undeclared.monkey") + ":1:16: identifier not found: missing"),
+		},
+		{
+			input:          `import "unused"; unused.f()`,
+			expected:       2,
+			expectedErrOut: "WARNING: " + filepath.Join(dir, "unused.monkey") + ":1:20: `unused` is declared but never used\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+
+		l := lexer.NewFileLexer(filepath.Join(dir, "main.monkey"), tt.input)
+		p := parser.NewParser(l)
+		e := New()
+		e.Out = &out
+		e.ErrOut = &errOut
+		evaluated := e.Eval(p.ParseProgram(), object.NewEnvironment())
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+
+		if errOut.String() != tt.expectedErrOut {
+			t.Errorf("wrong warnings for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expectedErrOut, errOut.String())
+		}
+
+		if loads := bytes.Count(out.Bytes(), []byte("loading math")); loads > 1 {
+			t.Errorf("module evaluated %d times for input `%s`", loads, tt.input)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "let x = 1; let f = fn(a) { a + x }; f(2)", expected: nil},
+		{input: "let f = fn() { g() }; let g = fn() { 1 }; f()", expected: nil},
+		{input: "if (false) { typo }", expected: []string{"ERROR: 1:14: identifier not found: typo"}},
+		{input: "let f = fn() { y = 1 }; f()", expected: []string{"ERROR: 1:16: cannot assign to undeclared identifier: y"}},
+		{input: "let x = 1; let x = 2; x", expected: []string{"WARNING: 1:16: `x` is already declared in this scope at 1:5"}},
+		{input: "let f = fn(a) { let a = 2; a }; f(1)", expected: []string{"WARNING: 1:21: `a` is already declared in this scope at 1:12"}},
+		{input: "let x = 1; let f = fn() { let x = 2; x }; f() + x", expected: nil},
+		{input: "let f = fn() { let unused = 1; let _ignored = 2; 3 }; f()", expected: []string{"WARNING: 1:20: `unused` is declared but never used"}},
+		{input: "let f = fn() { let n = 0; n = 1 }; f()", expected: []string{"WARNING: 1:20: `n` is declared but never used"}},
+		{input: "let f = fn() { let n = 0; n += 1 }; f()", expected: nil},
+		{input: "let unused = 1", expected: nil},
+		{input: "for (x in [1]) { x }; try { 1 } catch (e) { e }; x + e", expected: nil},
+		{input: "let f = fn(a, b = a, ...rest) { [b, rest] }; f(1)", expected: nil},
+		{input: "let f = fn(a = b) { a }; f()", expected: []string{"ERROR: 1:16: identifier not found: b"}},
+		{input: `len("a") + puts(1)`, expected: nil},
+		{input: "let x = 1; quote(unquote(x) + y)", expected: nil},
+		{input: "let f = fn() { if (true) { let v = 1 } v }; f()", expected: nil},
+		{input: "a + b", expected: []string{"ERROR: 1:1: identifier not found: a", "ERROR: 1:5: identifier not found: b"}},
+	}
+
+	for _, tt := range tests {
+		var actual []string
+		for _, diagnostic := range New().Resolve(testParseProgram(tt.input), object.NewEnvironment()) {
+			actual = append(actual, diagnostic.String())
+		}
+
+		if strings.Join(actual, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("wrong diagnostics for input `%s`. expected=`%q`, actual=`%q`", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestResolvedEvaluation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "let x = 1; let f = fn() { let g = fn() { x }; g() }; f()", expected: 1},
+		{input: "let x = 1; let f = fn(c) { if (c) { let x = 2 } x }; [f(false), f(true)]", expected: []int{1, 2}},
+		{input: "let n = 0; let f = fn() { let g = fn() { n += 1 }; g(); g() }; f(); n", expected: 2},
+		{input: "let counter = fn() { let c = 0; fn() { c += 1 } }; let next = counter(); next(); next()", expected: 2},
+		{input: "let fib = fn(n) { if (n < 2) { return n } fib(n - 1) + fib(n - 2) }; fib(10)", expected: 55},
+		{input: "let x = 1; let x = x + 1; x", expected: 2},
+		{input: "let f = fn(a, b = a * 2, ...rest) { [a, b, len(rest)] }; concat(f(1), f(1, 5, 6, 7))", expected: []int{1, 2, 0, 1, 5, 2}},
+		{input: "let f = fn(a, a) { a }; f(1, 2)", expected: 2},
+		{input: "let n = 0; for (x in [1, 2, 3]) { n += x }; [n, x]", expected: []int{6, 3}},
+		{input: `let f = fn() { try { throw "a" } catch (e) { e["message"] } }; f()`, expected: "a"},
+		{input: "let f = fn() { let len = fn(x) { 0 }; len([1]) }; [f(), len([1])]", expected: []int{0, 1}},
+		{input: "global * 2", expected: 20},
+		{input: "let helper = fn(v) { v * 10 }; let m = macro(x) { quote(helper(unquote(x))) }; let f = fn(a, b) { m(a) }; [m(1), f(2, 3)]", expected: []int{10, 20}},
+		{input: "let m = macro(x) { quote(fn(a) { let q = 100; a + unquote(x) }(0) + unquote(x)) }; let f = fn() { let y = 1; m(y) }; f()", expected: 2},
+		{input: "let m = macro(x) { quote(fn() { let a = unquote(x); let t = 5; [a, t] }()) }; concat(m(try { 1 } catch (e) { 2 }), m(3))", expected: []int{1, 5, 3, 5}},
+	}
+
+	for _, tt := range tests {
+		e := New()
+		macroEnv := object.NewEnvironment()
+		program := testParseProgram(tt.input)
+		DefineMacros(program, macroEnv)
+		expanded, errObj := e.ExpandMacros(program, macroEnv)
+		if errObj != nil {
+			t.Fatalf("macro expansion failed for input `%s`: %s", tt.input, errObj.Inspect())
+		}
+		program = expanded.(*ast.Program)
+
+		env := object.NewEnvironment()
+		env.Set("global", &object.Integer{Value: 10})
+
+		for _, diagnostic := range e.Resolve(program, env) {
+			if !diagnostic.Warning {
+				t.Fatalf("unexpected diagnostic for input `%s`: %s", tt.input, diagnostic)
+			}
+		}
+
+		evaluated := e.Eval(program, env)
+		testExpectedObject(t, evaluated, tt.expected, tt.input)
+	}
+}
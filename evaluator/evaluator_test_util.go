@@ -1,12 +1,21 @@
 package evaluator
 
 import (
+	"monkey/ast"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
+func testParseProgram(input string) *ast.Program {
+	l := lexer.NewLexer(input)
+	p := parser.NewParser(l)
+	return p.ParseProgram()
+}
+
 func testEval(input string) object.Object {
 	l := lexer.NewLexer(input)
 	p := parser.NewParser(l)
@@ -31,6 +40,21 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64, input st
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, expected float64, input string) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("wrong obj type for input `%s`. expected=`*object.Float`, actual=`%T`", input, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("wrong result.Value for input `%s`. expected=%g, actual=%g", input, expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
 func testBooleanObject(t *testing.T, obj object.Object, expected bool, input string) bool {
 	result, ok := obj.(*object.Boolean)
 	if !ok {
@@ -53,3 +77,97 @@ func testNullObject(t *testing.T, obj object.Object, input string) bool {
 	}
 	return true
 }
+
+// errorMessage is the expected value of a test that should produce an error with the given message.
+type errorMessage string
+
+// testExpectedObject checks `obj` against `expected`, which is an int, float64, bool, string, []int, []string, nil
+// for NULL, or an errorMessage.
+func testExpectedObject(t *testing.T, obj object.Object, expected interface{}, input string) bool {
+	switch expected := expected.(type) {
+	case int:
+		return testIntegerObject(t, obj, int64(expected), input)
+	case float64:
+		return testFloatObject(t, obj, expected, input)
+	case bool:
+		return testBooleanObject(t, obj, expected, input)
+	case string:
+		return testStringObject(t, obj, expected, input)
+	case []int:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			t.Errorf("wrong obj type for input `%s`. expected=`*object.Array`, actual=`%T(%+v)`", input, obj, obj)
+			return false
+		}
+		if len(arr.Elements) != len(expected) {
+			t.Errorf("wrong number of elements for input `%s`. expected=`%d`, actual=`%d`", input, len(expected), len(arr.Elements))
+			return false
+		}
+		for i, element := range expected {
+			if !testIntegerObject(t, arr.Elements[i], int64(element), input) {
+				return false
+			}
+		}
+		return true
+	case []string:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			t.Errorf("wrong obj type for input `%s`. expected=`*object.Array`, actual=`%T(%+v)`", input, obj, obj)
+			return false
+		}
+		if len(arr.Elements) != len(expected) {
+			t.Errorf("wrong number of elements for input `%s`. expected=`%d`, actual=`%d`", input, len(expected), len(arr.Elements))
+			return false
+		}
+		for i, element := range expected {
+			if !testStringObject(t, arr.Elements[i], element, input) {
+				return false
+			}
+		}
+		return true
+	case errorMessage:
+		errObj, ok := obj.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for input `%s`. actual=`%T(%#v)`", input, obj, obj)
+			return false
+		}
+		if errObj.Message != string(expected) {
+			t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", input, expected, errObj.Message)
+			return false
+		}
+		return true
+	case nil:
+		return testNullObject(t, obj, input)
+	default:
+		t.Fatalf("unsupported expected value %T for input `%s`", expected, input)
+		return false
+	}
+}
+
+func testStringObject(t *testing.T, obj object.Object, expected string, input string) bool {
+	result, ok := obj.(*object.String)
+	if !ok {
+		t.Errorf("wrong obj type for input `%s`. expected=`*object.String`, actual=`%T(%+v)`", input, obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("wrong result.Value for input `%s`. expected=`%s`, actual=`%s`", input, expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
+// testWriteFiles writes `files`, which maps paths relative to `dir` to their contents.
+func testWriteFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("cannot create directory: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("cannot write file: %s", err)
+		}
+	}
+}
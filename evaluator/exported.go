@@ -0,0 +1,94 @@
+package evaluator
+
+import (
+	"io"
+	"monkey/object"
+	"monkey/token"
+	"os"
+)
+
+// The functions below expose the semantics of `Eval` to the vm package, so both engines agree on every operator.
+
+func (e *Evaluator) InfixOperator(operator string, left object.Object, right object.Object) object.Object {
+	return e.evalInfixExpression(operator, left, right)
+}
+
+func (e *Evaluator) PrefixOperator(operator string, right object.Object) object.Object {
+	return e.evalPrefixExpression(operator, right)
+}
+
+func Index(left object.Object, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// IndexAssignment performs `left[index] = value`, or a compound assignment like `left[index] += value` when `operator` is
+// not empty.
+func (e *Evaluator) IndexAssignment(operator string, left, index, value object.Object) object.Object {
+	return e.evalIndexAssignment(operator, left, index, value)
+}
+
+// Slice evaluates `left[low:high]`, where a NULL bound stands for a bound that was left out.
+func Slice(left, low, high object.Object) object.Object {
+	return evalSlice(left, low, high)
+}
+
+func Iterate(iterable object.Object) object.Object {
+	return iterate(iterable)
+}
+
+func Interpolate(parts []object.Object) *object.String {
+	return interpolate(parts)
+}
+
+func BooleanObject(value bool) *object.Boolean {
+	return nativeToBooleanObject(value)
+}
+
+// ApplyFunction calls `fn`, which is either a function or a built-in function, with `args`.
+func (e *Evaluator) ApplyFunction(fn object.Object, args []object.Object) object.Object {
+	// the call is made by Go code, so it has no position in the source
+	return e.applyFunction(fn, args, token.Position{})
+}
+
+// ApplyFunctionAt calls `fn` like `ApplyFunction`, for a call at `pos`, e.g. a call of the VM.
+func (e *Evaluator) ApplyFunctionAt(fn object.Object, args []object.Object, pos token.Position) object.Object {
+	return e.applyFunction(fn, args, pos)
+}
+
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
+
+func LookupBuiltIn(name string) (*object.BuiltIn, bool) {
+	builtIn, ok := builtIns[name]
+	return builtIn, ok
+}
+
+// Throw returns the error that `throw value` raises.
+func Throw(value object.Object) *object.Error {
+	return thrownError(value)
+}
+
+// ErrorValue returns the value that a `catch` block receives for `errObj`.
+func ErrorValue(errObj *object.Error) *object.Hash {
+	return errorValue(errObj)
+}
+
+// ArityError returns the error for calling a function with a wrong number of arguments, or nil if the number is right.
+func ArityError(name string, required int, params int, rest bool, numArgs int) *object.Error {
+	return arityError(name, required, params, rest, numArgs)
+}
+
+// Import returns the module at `path`, which is imported by the file `from`. The module is evaluated by `e`, so its
+// functions are `*object.Function` values.
+func (e *Evaluator) Import(path object.Object, from string) object.Object {
+	return e.importModule(path, from)
+}
+
+// Output returns the writer of `puts`, `print` and `printf`.
+func (e *Evaluator) Output() io.Writer {
+	if e.Out == nil {
+		return os.Stdout
+	}
+	return e.Out
+}
@@ -0,0 +1,103 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/object"
+	"strconv"
+	"strings"
+)
+
+// formatString replaces the verbs of `format`, which are like those of Go's fmt package, with `args`:
+//
+//	%d  an integer
+//	%f  a number, e.g. `%.2f`
+//	%s  any value, shown like `puts` shows it, e.g. an array
+//	%v  the same as %s
+//	%q  a string in double quotes
+//	%%  a literal percent sign
+//
+// A verb may have a width, which pads the value with spaces on the left, or on the right with the `-` flag. `%05d`
+// pads with zeros instead.
+func formatString(format string, args []object.Object) (string, *object.Error) {
+	var out strings.Builder
+	argIndex := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("-+0 ", format[i]) >= 0 {
+			i++
+		}
+		i = skipDigits(format, i)
+		if i < len(format) && format[i] == '.' {
+			i = skipDigits(format, i+1)
+		}
+		if i >= len(format) {
+			return "", newError("incomplete verb `%s` at the end of the format", format[start:])
+		}
+
+		verb := format[start : i+1]
+		if format[i] == '%' {
+			if len(verb) > 2 {
+				return "", newError("unknown verb `%s` in format", verb)
+			}
+			out.WriteByte('%')
+			continue
+		}
+
+		if argIndex >= len(args) {
+			return "", newError("missing argument for `%s` in format", verb)
+		}
+		formatted, err := formatVerb(verb, args[argIndex])
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(formatted)
+		argIndex++
+	}
+
+	if argIndex < len(args) {
+		return "", newError("too many arguments for format. expected=`%d`, actual=`%d`", argIndex, len(args))
+	}
+
+	return out.String(), nil
+}
+
+// formatVerb formats `arg` with `verb`, e.g. `%-5d`, whose flags, width and precision are handed to Go's fmt package.
+func formatVerb(verb string, arg object.Object) (string, *object.Error) {
+	switch verb[len(verb)-1] {
+	case 'd':
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return "", newError("argument for `%s` is not supported. expected=`INTEGER`, actual=`%s`", verb, arg.Type())
+		}
+		return fmt.Sprintf(verb, integer.Value), nil
+	case 'f':
+		if !isNumber(arg) {
+			return "", newError("argument for `%s` is not supported. expected=`INTEGER or FLOAT`, actual=`%s`", verb, arg.Type())
+		}
+		return fmt.Sprintf(verb, toFloat(arg)), nil
+	case 's', 'v':
+		return fmt.Sprintf(verb[:len(verb)-1]+"s", arg.Inspect()), nil
+	case 'q':
+		str, ok := arg.(*object.String)
+		if !ok {
+			return "", newError("argument for `%s` is not supported. expected=`STRING`, actual=`%s`", verb, arg.Type())
+		}
+		return fmt.Sprintf(verb[:len(verb)-1]+"s", strconv.Quote(str.Value)), nil
+	default:
+		return "", newError("unknown verb `%s` in format", verb)
+	}
+}
+
+func skipDigits(s string, i int) int {
+	for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+		i++
+	}
+	return i
+}
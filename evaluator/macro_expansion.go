@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// DefineMacros moves the top-level `let name = macro(...) { ... };` statements of `program` into `env`.
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	statements := []ast.Statement{}
+
+	for _, statement := range program.Statements {
+		letStatement, ok := statement.(*ast.LetStatement)
+		if !ok {
+			statements = append(statements, statement)
+			continue
+		}
+
+		macroLiteral, ok := letStatement.Value.(*ast.MacroLiteral)
+		if !ok {
+			statements = append(statements, statement)
+			continue
+		}
+
+		env.Set(letStatement.Name.Value, &object.Macro{
+			Parameters: macroLiteral.Parameters,
+			Body:       macroLiteral.Body,
+			Env:        env,
+		})
+	}
+
+	program.Statements = statements
+}
+
+// ExpandMacros expands the macros of `program` with the default configuration.
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	return New().ExpandMacros(program, env)
+}
+
+// ExpandMacros replaces the calls of the macros defined in `env` with the code they return. The arguments are passed as
+// `*object.Quote` objects, without being evaluated. The first error stops the expansion.
+func (e *Evaluator) ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var err *object.Error
+
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if !ok || err != nil {
+			return node
+		}
+
+		macro, ok := lookupMacro(call, env)
+		if !ok {
+			return node
+		}
+
+		if len(call.Arguments) != len(macro.Parameters) {
+			err = newError("wrong argument count for macro `%s`. expected=`%d`, actual=`%d`",
+				call.Function, len(macro.Parameters), len(call.Arguments))
+			err.Pos = call.Pos()
+			return node
+		}
+
+		evalEnv := object.NewEnclosedEnvironment(macro.Env)
+		for i, param := range macro.Parameters {
+			evalEnv.Set(param.Value, &object.Quote{Node: call.Arguments[i]})
+		}
+
+		evaluated := unwrapReturnValue(e.Eval(macro.Body, evalEnv))
+		if evaluated == nil {
+			evaluated = Null
+		}
+
+		switch evaluated := evaluated.(type) {
+		case *object.Quote:
+			return evaluated.Node
+		case *object.Error:
+			err = evaluated
+		default:
+			err = newError("macro `%s` must return QUOTE. actual=`%s`", call.Function, evaluated.Type())
+			err.Pos = call.Pos()
+		}
+
+		return node
+	})
+
+	return expanded, err
+}
+
+func lookupMacro(call *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+
+	obj, ok := env.Get(ident.Value)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := obj.(*object.Macro)
+	return macro, ok
+}
@@ -0,0 +1,123 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"testing"
+)
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+	let number = 1;
+	let function = fn(x, y) { x + y };
+	let mymacro = macro(x, y) { x + y; };
+	`
+
+	env := object.NewEnvironment()
+	program := testParseProgram(input)
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements. expected=`2`, actual=`%d`", len(program.Statements))
+	}
+
+	if _, ok := env.Get("number"); ok {
+		t.Fatalf("number should not be defined")
+	}
+	if _, ok := env.Get("function"); ok {
+		t.Fatalf("function should not be defined")
+	}
+
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment")
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("wrong object type. expected=`*object.Macro`, actual=`%T`", obj)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("wrong number of macro parameters. expected=`2`, actual=`%d`", len(macro.Parameters))
+	}
+
+	if macro.Body.String() != "(x + y)" {
+		t.Fatalf("wrong macro body. expected=`(x + y)`, actual=`%s`", macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "let infixExpression = macro() { quote(1 + 2); }; infixExpression();",
+			expected: "(1 + 2)",
+		},
+		{
+			input:    "let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); }; reverse(2 + 2, 10 - 5);",
+			expected: "(10 - 5) - (2 + 2)",
+		},
+		{
+			input: `
+			let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) { unquote(consequence); } else { unquote(alternative); });
+			};
+			unless(10 > 5, puts("not greater"), puts("greater"));
+			`,
+			expected: `if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+		{
+			// the macro body is not changed by an expansion, so a second call gets its own arguments
+			input:    "let double = macro(x) { quote(unquote(x) * 2) }; double(1); double(a);",
+			expected: "(1 * 2); (a * 2)",
+		},
+		{
+			input:    "let inner = macro(x) { quote(unquote(x) + 1) }; let outer = macro(x) { quote(inner(unquote(x))) }; outer(a);",
+			expected: "inner(a)",
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("macro expansion failed for input `%s`: %s", tt.input, err.Inspect())
+		}
+
+		if expanded.String() != expected.String() {
+			t.Errorf("wrong expanded program for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []ErrorTest{
+		{input: "let m = macro(x) { quote(x) }; m();", expectedMessage: "wrong argument count for macro `m`. expected=`1`, actual=`0`"},
+		{input: "let m = macro() { 1 }; m();", expectedMessage: "macro `m` must return QUOTE. actual=`INTEGER`"},
+		{input: "let m = macro() { }; m();", expectedMessage: "macro `m` must return QUOTE. actual=`NULL`"},
+		{input: "let m = macro() { 1 + true }; m();", expectedMessage: "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		if err == nil {
+			t.Errorf("no error for input `%s`", tt.input)
+			continue
+		}
+
+		if err.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expectedMessage, err.Message)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package evaluator
+
+import (
+	"io"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// moduleExtension is added to an import path without an extension, so `import "lib/math"` finds `lib/math.monkey`.
+const moduleExtension = ".monkey"
+
+// modules is the state of `import`, which is shared by every evaluation of an evaluator.
+type modules struct {
+	loaded    map[string]*object.Module // by resolved path
+	importing []string                  // resolved paths of the modules being evaluated, the innermost last
+}
+
+func (e *Evaluator) evalImportExpression(node *ast.ImportExpression, env *object.Environment) object.Object {
+	path := e.Eval(node.Path, env)
+	if isError(path) {
+		return path
+	}
+
+	return e.importModule(path, node.Pos().Filename)
+}
+
+// importModule returns the module at `pathObj`, which is imported by the file `from`. A module is evaluated the first
+// time it is imported, later imports return the same module.
+func (e *Evaluator) importModule(pathObj object.Object, from string) object.Object {
+	str, ok := pathObj.(*object.String)
+	if !ok {
+		return newError("import path must be STRING. actual=`%s`", pathObj.Type())
+	}
+	path := str.Value
+
+	resolved, ok := resolveModule(path, from)
+	if !ok {
+		return newError("module not found: %s", path)
+	}
+
+	if module, ok := e.modules.loaded[resolved]; ok {
+		return module
+	}
+
+	for i, importing := range e.modules.importing {
+		if importing == resolved {
+			cycle := append(append([]string{}, e.modules.importing[i:]...), resolved)
+			return newError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	source, err := os.ReadFile(resolved)
+	if err != nil {
+		return newError("cannot read module %s: %s", path, err)
+	}
+
+	p := parser.NewParser(lexer.NewFileLexer(resolved, string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return newError("cannot parse module %s:\n\t%s", path, strings.Join(p.Errors(), "\n\t"))
+	}
+
+	macroEnv := object.NewEnvironment()
+	DefineMacros(program, macroEnv)
+	expanded, errObj := e.ExpandMacros(program, macroEnv)
+	if errObj != nil {
+		return errObj
+	}
+
+	env := object.NewEnvironment()
+	var failures []string
+	for _, diagnostic := range e.Resolve(expanded.(*ast.Program), env) {
+		if diagnostic.Warning {
+			io.WriteString(e.errOutput(), diagnostic.String()+"\n")
+		} else {
+			failures = append(failures, diagnostic.Pos.String()+": "+diagnostic.Message)
+		}
+	}
+	if len(failures) > 0 {
+		return newError("cannot resolve module %s:\n\t%s", path, strings.Join(failures, "\n\t"))
+	}
+
+	e.modules.importing = append(e.modules.importing, resolved)
+	result := e.Eval(expanded, env)
+	e.modules.importing = e.modules.importing[:len(e.modules.importing)-1]
+
+	if isError(result) {
+		return result
+	}
+
+	module := &object.Module{
+		Name:    strings.TrimSuffix(filepath.Base(resolved), filepath.Ext(resolved)),
+		Path:    resolved,
+		Exports: make(map[string]object.Object),
+	}
+	for name, value := range env.Bindings() {
+		if !strings.HasPrefix(name, "_") {
+			module.Exports[name] = value
+		}
+	}
+
+	if e.modules.loaded == nil {
+		e.modules.loaded = make(map[string]*object.Module)
+	}
+	e.modules.loaded[resolved] = module
+
+	return module
+}
+
+// errOutput returns the writer of the warnings about imported modules.
+func (e *Evaluator) errOutput() io.Writer {
+	if e.ErrOut == nil {
+		return os.Stderr
+	}
+	return e.ErrOut
+}
+
+// resolveModule returns the absolute path of the module file at `path`. A relative path is looked up in the directory
+// of `from`, or the working directory when `from` is empty, and then in the directories listed by MONKEYPATH.
+func resolveModule(path string, from string) (string, bool) {
+	names := []string{path}
+	if filepath.Ext(path) == "" {
+		names = append(names, path+moduleExtension)
+	}
+
+	dirs := []string{""}
+	if !filepath.IsAbs(path) {
+		dirs = append([]string{filepath.Dir(from)}, filepath.SplitList(os.Getenv("MONKEYPATH"))...)
+	}
+
+	for _, dir := range dirs {
+		for _, name := range names {
+			candidate := filepath.Join(dir, name)
+
+			info, err := os.Stat(candidate)
+			if err != nil || info.IsDir() {
+				continue
+			}
+
+			if abs, err := filepath.Abs(candidate); err == nil {
+				return abs, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func evalModuleIndexExpression(module, index object.Object) object.Object {
+	moduleObject := module.(*object.Module)
+	name := index.(*object.String).Value
+
+	value, ok := moduleObject.Exports[name]
+	if !ok {
+		return newError("export not found: %s.%s", moduleObject.Name, name)
+	}
+
+	return value
+}
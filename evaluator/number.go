@@ -0,0 +1,31 @@
+package evaluator
+
+import (
+	"math"
+	"monkey/object"
+)
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.IntegerObj || obj.Type() == object.FloatObj
+}
+
+// toFloat converts an INTEGER or FLOAT to a float64; any other object converts to 0.
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
+// floatToInteger truncates `value` towards zero, failing when the result doesn't fit in an int64.
+func floatToInteger(value float64) object.Object {
+	// -2^63 is exactly representable, 2^63 is the first float past the largest int64
+	if math.IsNaN(value) || value < math.MinInt64 || value >= -math.MinInt64 {
+		return newError("cannot convert %s to %s", (&object.Float{Value: value}).Inspect(), object.IntegerObj)
+	}
+	return &object.Integer{Value: int64(value)}
+}
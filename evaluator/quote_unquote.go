@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+)
+
+// quote returns the code of `node`, after replacing the `unquote(...)` calls in it with the value of their argument.
+func (e *Evaluator) quote(node ast.Node, env *object.Environment) object.Object {
+	var err *object.Error
+
+	node = ast.Modify(node, func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if !ok || !isCallTo(call, "unquote") || err != nil {
+			return node
+		}
+
+		if len(call.Arguments) != 1 {
+			err = newError("wrong argument count for `unquote` function. expected=`1`, actual=`%d`", len(call.Arguments))
+			err.Pos = call.Pos()
+			return node
+		}
+
+		unquoted := e.Eval(call.Arguments[0], env)
+		if errObj, ok := unquoted.(*object.Error); ok {
+			err = errObj
+			return node
+		}
+
+		converted, convErr := objectToASTNode(unquoted, call)
+		if convErr != nil {
+			err = convErr
+			return node
+		}
+
+		return converted
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return &object.Quote{Node: node}
+}
+
+// objectToASTNode turns the value of `unquote(...)` back into code, positioned at the `unquote` call.
+func objectToASTNode(obj object.Object, call *ast.CallExpression) (ast.Node, *object.Error) {
+	tok := token.Token{Pos: call.Pos(), End: call.End()}
+
+	switch obj := obj.(type) {
+	case *object.Integer:
+		tok.Type = token.Integer
+		tok.Literal = fmt.Sprintf("%d", obj.Value)
+		return &ast.IntegerLiteral{Token: tok, Value: obj.Value}, nil
+	case *object.Float:
+		tok.Type = token.Float
+		tok.Literal = obj.Inspect()
+		return &ast.FloatLiteral{Token: tok, Value: obj.Value}, nil
+	case *object.Boolean:
+		if obj.Value {
+			tok.Type = token.True
+		} else {
+			tok.Type = token.False
+		}
+		tok.Literal = fmt.Sprintf("%t", obj.Value)
+		return &ast.BooleanLiteral{Token: tok, Value: obj.Value}, nil
+	case *object.String:
+		tok.Type = token.String
+		tok.Literal = obj.Value
+		return &ast.StringLiteral{Token: tok, Value: obj.Value}, nil
+	case *object.Quote:
+		// the code is copied, so that code unquoted twice gets resolved for each place it ends up in
+		return ast.Modify(obj.Node, func(node ast.Node) ast.Node { return node }), nil
+	default:
+		err := newError("cannot unquote %s", obj.Type())
+		err.Pos = call.Pos()
+		return nil, err
+	}
+}
+
+func isCallTo(call *ast.CallExpression, name string) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == name
+}
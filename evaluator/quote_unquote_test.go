@@ -0,0 +1,84 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"testing"
+)
+
+type QuoteTest struct {
+	input    string
+	expected string
+}
+
+func TestQuote(t *testing.T) {
+	tests := []QuoteTest{
+		{input: "quote(5)", expected: "5"},
+		{input: "quote(5 + 8)", expected: "(5 + 8)"},
+		{input: "quote(foobar)", expected: "foobar"},
+		{input: "quote(foobar + barfoo)", expected: "(foobar + barfoo)"},
+	}
+
+	testQuotes(t, tests)
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []QuoteTest{
+		{input: "quote(unquote(4))", expected: "4"},
+		{input: "quote(unquote(4 + 4))", expected: "8"},
+		{input: "quote(8 + unquote(4 + 4))", expected: "(8 + 8)"},
+		{input: "quote(unquote(4 + 4) + 8)", expected: "(8 + 8)"},
+		{input: "let foobar = 8; quote(foobar)", expected: "foobar"},
+		{input: "let foobar = 8; quote(unquote(foobar))", expected: "8"},
+		{input: "quote(unquote(1.5 * 3))", expected: "4.5"},
+		{input: "quote(unquote(true))", expected: "true"},
+		{input: `quote("x = ${unquote(1 + 2)}")`, expected: `"x = ${3}"`},
+		{input: "quote(unquote(true == false))", expected: "false"},
+		{input: `quote(unquote("a" + "b"))`, expected: "ab"},
+		{input: "quote(unquote(quote(4 + 4)))", expected: "(4 + 4)"},
+		{input: "let quotedInfixExpression = quote(4 + 4); quote(unquote(4 + 4) + unquote(quotedInfixExpression))", expected: "(8 + (4 + 4))"},
+	}
+
+	testQuotes(t, tests)
+}
+
+func TestQuoteErrors(t *testing.T) {
+	tests := []ErrorTest{
+		{input: "quote()", expectedMessage: "wrong argument count for `quote` function. expected=`1`, actual=`0`"},
+		{input: "quote(unquote(1, 2))", expectedMessage: "wrong argument count for `unquote` function. expected=`1`, actual=`2`"},
+		{input: "quote(unquote(1 + true))", expectedMessage: "type mismatch: INTEGER + BOOLEAN"},
+		{input: "quote(unquote([1]))", expectedMessage: "cannot unquote ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for input `%s`. actual=`%T(%#v)`", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func testQuotes(t *testing.T, tests []QuoteTest) {
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("wrong object type for input `%s`. expected=`*object.Quote`, actual=`%T(%+v)`", tt.input, evaluated, evaluated)
+		}
+
+		if quote.Node == nil {
+			t.Fatalf("quote.Node is nil for input `%s`", tt.input)
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("wrong quote.Node.String() for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expected, quote.Node.String())
+		}
+	}
+}
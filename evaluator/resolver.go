@@ -0,0 +1,251 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+	"sort"
+	"strings"
+)
+
+// Diagnostic is a problem that `Resolve` finds before the program runs.
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+	// Warning marks a problem that doesn't stop the program, e.g. an unused binding.
+	Warning bool
+}
+
+func (d Diagnostic) String() string {
+	if d.Warning {
+		return fmt.Sprintf("WARNING: %s: %s", d.Pos, d.Message)
+	}
+
+	return fmt.Sprintf("ERROR: %s: %s", d.Pos, d.Message)
+}
+
+// bindingKind is how a name was bound, which decides the diagnostics about it.
+type bindingKind int
+
+const (
+	letBinding    bindingKind = iota
+	globalBinding             // a binding that the environment the program runs in already has
+	parameterBinding
+	loopBinding  // the variable of a `for` loop
+	catchBinding // the parameter of a `catch` block
+)
+
+type binding struct {
+	name *ast.Identifier // nil for a global binding
+	kind bindingKind
+	slot int
+	used bool
+}
+
+// scope is the environment of a function call, or of the whole program. Blocks don't have their own scope, so every
+// name bound in a function belongs to the scope of that function.
+type scope struct {
+	outer    *scope
+	bindings map[string]*binding
+	names    []string // the name of each slot
+}
+
+type resolver struct {
+	scope       *scope
+	diagnostics []Diagnostic
+}
+
+// Resolve checks the names of `program` before it runs: it reports the identifiers that are never bound as errors, and
+// `let` statements that bind a name again in the same scope and bindings that are never used as warnings. Top-level
+// bindings are not reported as unused, since they are what a module exports.
+//
+// Resolve also gives each binding of a scope a slot, and records in each identifier how many scopes out its binding is
+// and in which slot, so the binding is found without looking up its name. `env` is the environment the program will
+// run in, whose bindings the program can use, and which must not get other bindings until then.
+func (e *Evaluator) Resolve(program *ast.Program, env *object.Environment) []Diagnostic {
+	r := &resolver{}
+	r.resolveScope(program, env.Names())
+
+	sort.SliceStable(r.diagnostics, func(i, j int) bool {
+		return r.diagnostics[i].Pos.Offset < r.diagnostics[j].Pos.Offset
+	})
+	return r.diagnostics
+}
+
+// resolveScope resolves `node`, which is the program or a function, in a new scope. All names of the scope are declared
+// before any of them is resolved, because a function can refer to a name that is bound after the function itself,
+// e.g. when two functions call each other.
+func (r *resolver) resolveScope(node ast.Node, globals []string) {
+	r.scope = &scope{outer: r.scope, bindings: make(map[string]*binding)}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, name := range globals {
+			r.scope.bindings[name] = &binding{kind: globalBinding, slot: len(r.scope.names)}
+			r.scope.names = append(r.scope.names, name)
+		}
+
+		r.declareBindings(node)
+		r.resolve(node)
+		node.Slots = r.scope.names
+	case *ast.FunctionLiteral:
+		for _, param := range node.Parameters {
+			r.declare(param, parameterBinding)
+		}
+		if node.Rest != nil {
+			r.declare(node.Rest, parameterBinding)
+		}
+		r.declareBindings(node.Body)
+
+		for _, value := range node.Defaults {
+			r.resolve(value)
+		}
+		r.resolve(node.Body)
+		r.reportUnused()
+		node.Slots = r.scope.names
+	}
+
+	r.scope = r.scope.outer
+}
+
+// declareBindings declares the names that `node` binds in the current scope, without the ones of nested functions.
+func (r *resolver) declareBindings(node ast.Node) {
+	ast.Walk(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			r.declare(node.Name, letBinding)
+		case *ast.ForStatement:
+			r.declare(node.Variable, loopBinding)
+		case *ast.TryExpression:
+			if node.CatchParameter != nil {
+				r.declare(node.CatchParameter, catchBinding)
+			}
+		case *ast.FunctionLiteral, *ast.MacroLiteral:
+			return false
+		}
+
+		return true
+	})
+}
+
+// declare binds `name` in the current scope. Binding a name again reuses its slot, since the environment has one
+// binding for each name.
+func (r *resolver) declare(name *ast.Identifier, kind bindingKind) {
+	b, ok := r.scope.bindings[name.Value]
+	if ok {
+		if kind == letBinding && (b.kind == letBinding || b.kind == parameterBinding) {
+			r.warn(name.Pos(), "`%s` is already declared in this scope at %s", name.Value, b.name.Pos())
+		}
+	} else {
+		b = &binding{name: name, kind: kind, slot: len(r.scope.names)}
+		r.scope.bindings[name.Value] = b
+		r.scope.names = append(r.scope.names, name.Value)
+	}
+
+	name.Resolved, name.Depth, name.Slot = true, 0, b.slot
+}
+
+// resolve resolves the identifiers in `node`, which belong to the current scope.
+func (r *resolver) resolve(node ast.Node) {
+	ast.Walk(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			if !r.resolveIdentifier(node, true) {
+				r.fail(node.Pos(), "identifier not found: %s", node.Value)
+			}
+		case *ast.LetStatement:
+			r.resolve(node.Value)
+			return false
+		case *ast.ForStatement:
+			r.resolve(node.Iterable)
+			r.resolve(node.Body)
+			return false
+		case *ast.TryExpression:
+			r.resolve(node.Block)
+			if node.Catch != nil {
+				r.resolve(node.Catch)
+			}
+			if node.Finally != nil {
+				r.resolve(node.Finally)
+			}
+			return false
+		case *ast.AssignExpression:
+			if target, ok := node.Target.(*ast.Identifier); ok {
+				// a plain assignment doesn't read the variable, so it doesn't count as a use
+				if !r.resolveIdentifier(target, node.Operator != "=") {
+					r.fail(target.Pos(), "cannot assign to undeclared identifier: %s", target.Value)
+				}
+				r.resolve(node.Value)
+				return false
+			}
+		case *ast.CallExpression:
+			if isCallTo(node, "quote") {
+				r.resolveUnquoted(node)
+				return false
+			}
+		case *ast.FunctionLiteral:
+			r.resolveScope(node, nil)
+			return false
+		case *ast.MacroLiteral:
+			return false
+		}
+
+		return true
+	})
+}
+
+// resolveUnquoted resolves the arguments of the `unquote(...)` calls in a `quote(...)` call, which are the only code
+// of it that runs.
+func (r *resolver) resolveUnquoted(quote *ast.CallExpression) {
+	for _, arg := range quote.Arguments {
+		ast.Walk(arg, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpression)
+			if !ok || !isCallTo(call, "unquote") {
+				return true
+			}
+
+			for _, arg := range call.Arguments {
+				r.resolve(arg)
+			}
+			return false
+		})
+	}
+}
+
+// resolveIdentifier looks up the binding of `ident`, and records where it is. It reports whether the identifier is
+// bound, either by the program or as a built-in function. `use` tells whether the binding is read.
+func (r *resolver) resolveIdentifier(ident *ast.Identifier, use bool) bool {
+	depth := 0
+	for s := r.scope; s != nil; s = s.outer {
+		if b, ok := s.bindings[ident.Value]; ok {
+			b.used = b.used || use
+			ident.Resolved, ident.Depth, ident.Slot = true, depth, b.slot
+			return true
+		}
+		depth++
+	}
+
+	ident.Resolved = false
+
+	_, ok := builtIns[ident.Value]
+	return ok
+}
+
+// reportUnused reports the `let` bindings of the current scope that are never used. A name starting with `_` marks a
+// binding that is unused on purpose.
+func (r *resolver) reportUnused() {
+	for name, b := range r.scope.bindings {
+		if b.kind == letBinding && !b.used && !strings.HasPrefix(name, "_") {
+			r.warn(b.name.Pos(), "`%s` is declared but never used", name)
+		}
+	}
+}
+
+func (r *resolver) fail(pos token.Position, format string, a ...interface{}) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{Pos: pos, Message: fmt.Sprintf(format, a...)})
+}
+
+func (r *resolver) warn(pos token.Position, format string, a ...interface{}) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{Pos: pos, Message: fmt.Sprintf(format, a...), Warning: true})
+}
@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// An error that a `catch` block receives is an error value: a hash with the message and the stack of the error. The
+// same kind of hash is created by the `error` built-in function.
+var (
+	messageKey = &object.String{Value: "message"}
+	stackKey   = &object.String{Value: "stack"}
+)
+
+func (e *Evaluator) evalThrowStatement(node *ast.ThrowStatement, env *object.Environment) object.Object {
+	value := e.Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	return thrownError(value)
+}
+
+// thrownError returns the error that `throw value` raises. The value is either the message, or an error value whose
+// message is raised again.
+func thrownError(value object.Object) *object.Error {
+	switch value := value.(type) {
+	case *object.String:
+		return newError("%s", value.Value)
+	case *object.Hash:
+		if message, ok := value.Get(messageKey); ok {
+			if message, ok := message.(*object.String); ok {
+				return newError("%s", message.Value)
+			}
+		}
+	}
+
+	return newError("cannot throw %s, only a STRING or an error value", value.Type())
+}
+
+// errorValue turns an error into the value a `catch` block receives.
+func errorValue(errObj *object.Error) *object.Hash {
+	frames := make([]object.Object, len(errObj.Stack))
+	for i, frame := range errObj.Stack {
+		frames[i] = &object.String{Value: frame.String()}
+	}
+
+	value := object.NewHash()
+	value.Set(messageKey, &object.String{Value: errObj.Message})
+	value.Set(stackKey, &object.Array{Elements: frames})
+
+	return value
+}
+
+func (e *Evaluator) evalTryExpression(node *ast.TryExpression, env *object.Environment) object.Object {
+	result := e.Eval(node.Block, env)
+
+	if errObj, ok := result.(*object.Error); ok && node.Catch != nil {
+		bind(env, node.CatchParameter, errorValue(errObj))
+		result = e.Eval(node.Catch, env)
+	}
+
+	if node.Finally != nil {
+		// the `finally` block only changes the outcome when it stops running itself, e.g. by an error or a `return`
+		finally := e.Eval(node.Finally, env)
+		if finally != nil {
+			switch finally.Type() {
+			case object.ReturnValueObj, object.ErrorObj, object.BreakObj, object.ContinueObj:
+				return finally
+			}
+		}
+	}
+
+	if result == nil {
+		return Null
+	}
+
+	return result
+}
@@ -2,16 +2,31 @@ package file
 
 import (
 	"bufio"
+	"errors"
+	"io"
+	"monkey/ast"
+	"monkey/compiler"
+	"monkey/evaluator"
 	"monkey/lexer"
+	"monkey/object"
 	"monkey/parser"
 	"monkey/util"
+	"monkey/vm"
 	"os"
 )
 
-func Start(f *os.File) bool {
+type Options struct {
+	// DumpAST prints the parsed program instead of evaluating it.
+	DumpAST bool
+	// Engine is either `util.EngineEval` or `util.EngineVM`. The evaluator is used when it is empty.
+	Engine string
+	// CheckedArithmetic makes integer overflow a runtime error instead of wrapping around.
+	CheckedArithmetic bool
+}
+
+func Start(f *os.File, options Options) bool {
 	defer f.Close()
 
-	out := os.Stdout
 	scanner := bufio.NewScanner(f)
 	code := ""
 	for scanner.Scan() {
@@ -19,16 +34,81 @@ func Start(f *os.File) bool {
 		code = code + line + "\n"
 	}
 
-	l := lexer.NewLexer(code)
+	return run(f.Name(), code, options, os.Stdout, os.Stderr)
+}
+
+func run(filename string, code string, options Options, out io.Writer, errOut io.Writer) bool {
+	l := lexer.NewFileLexer(filename, code)
 	p := parser.NewParser(l)
 
 	program := p.ParseProgram()
 	if len(p.Errors()) > 0 {
-		util.PrintParserErrors(out, p.Errors())
+		util.PrintParserErrors(errOut, p.Errors())
+		return false
+	}
+
+	if options.DumpAST {
+		io.WriteString(out, program.String()+"\n")
+		return true
+	}
+
+	eval := evaluator.New()
+	eval.CheckedArithmetic = options.CheckedArithmetic
+	eval.Out = out
+	eval.ErrOut = errOut
+
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	expanded, errObj := eval.ExpandMacros(program, macroEnv)
+	if errObj != nil {
+		io.WriteString(errOut, errObj.Inspect()+"\n")
 		return false
 	}
 
-	out.WriteString(program.String() + "\n")
+	env := object.NewEnvironment()
+	if !resolve(eval, expanded.(*ast.Program), env, errOut) {
+		return false
+	}
+
+	if options.Engine == util.EngineVM {
+		comp := compiler.New()
+		err := comp.Compile(expanded)
+		if err != nil {
+			io.WriteString(errOut, "ERROR: compilation failed: "+err.Error()+"\n")
+			return false
+		}
+
+		machine := vm.New(comp.Bytecode())
+		machine.Evaluator = eval
+		err = machine.Run()
+		var runtimeErr *vm.RuntimeError
+		if errors.As(err, &runtimeErr) {
+			io.WriteString(errOut, runtimeErr.Err.Traceback()+"\n")
+			return false
+		} else if err != nil {
+			io.WriteString(errOut, "ERROR: "+err.Error()+"\n")
+			return false
+		}
+
+		return true
+	}
+
+	evaluated := eval.Eval(expanded, env)
+	if errObj, ok := evaluated.(*object.Error); ok {
+		io.WriteString(errOut, errObj.Traceback()+"\n")
+		return false
+	}
 
 	return true
 }
+
+// resolve prints the diagnostics of `program`, and reports whether it can run, i.e. whether all of them are warnings.
+func resolve(eval *evaluator.Evaluator, program *ast.Program, env *object.Environment, errOut io.Writer) bool {
+	ok := true
+	for _, diagnostic := range eval.Resolve(program, env) {
+		io.WriteString(errOut, diagnostic.String()+"\n")
+		ok = ok && diagnostic.Warning
+	}
+
+	return ok
+}
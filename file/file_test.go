@@ -0,0 +1,63 @@
+package file
+
+import (
+	"bytes"
+	"monkey/util"
+	"testing"
+)
+
+type RunTest struct {
+	input          string
+	options        Options
+	expectedOk     bool
+	expectedOut    string
+	expectedErrOut string
+}
+
+func TestRun(t *testing.T) {
+	tests := []RunTest{
+		{input: "let a = 5; a * 2;", expectedOk: true},
+		{input: "let a = 5; a + b;", expectedOk: false, expectedErrOut: "ERROR: test.monkey:1:16: identifier not found: b\n"},
+		{input: "let a = ;", expectedOk: false, expectedErrOut: "\ttest.monkey:1:9: no prefix parse function for ; found\t\n"},
+		{input: "let a = 5 * 2;", options: Options{DumpAST: true}, expectedOk: true, expectedOut: "let a = (5 * 2);\n"},
+		{input: "let m = macro(x) { quote(unquote(x) + b) }; let b = 1; m(2);", expectedOk: true},
+		{input: "let m = macro(x) { quote(unquote(x) + b) }; m(2);", expectedOk: false, expectedErrOut: "ERROR: test.monkey:1:39: identifier not found: b\n"},
+		{input: "let m = macro(x) { quote(unquote(x) + 1) }; let b = m(2);", options: Options{Engine: util.EngineVM}, expectedOk: true},
+		{input: "9223372036854775807 + 1", options: Options{CheckedArithmetic: true}, expectedOk: false, expectedErrOut: "ERROR: test.monkey:1:1: integer overflow: 9223372036854775807 + 1\n"},
+		{input: "9223372036854775807 + 1", options: Options{Engine: util.EngineVM, CheckedArithmetic: true}, expectedOk: false, expectedErrOut: "ERROR: test.monkey:1:1: integer overflow: 9223372036854775807 + 1\n"},
+		{input: "let m = macro() { 1 };\nm();", expectedOk: false, expectedErrOut: "ERROR: test.monkey:2:1: macro `m` must return QUOTE. actual=`INTEGER`\n"},
+		{input: `puts("hi"); printf("%d!\n", 42);`, expectedOk: true, expectedOut: "hi\n42!\n"},
+		{input: `puts("hi"); printf("%d!\n", 42);`, options: Options{Engine: util.EngineVM}, expectedOk: true, expectedOut: "hi\n42!\n"},
+		{input: "let f = fn() { 1 };\nif (false) { typo() }\nputs(f())", expectedOk: false, expectedErrOut: "ERROR: test.monkey:2:14: identifier not found: typo\n"},
+		{input: "let f = fn() { 1 };\nif (false) { typo() }\nputs(f())", options: Options{Engine: util.EngineVM}, expectedOk: false, expectedErrOut: "ERROR: test.monkey:2:14: identifier not found: typo\n"},
+		{input: "let f = fn() { let unused = 1; 2 };\nputs(f())", expectedOk: true, expectedOut: "2\n", expectedErrOut: "WARNING: test.monkey:1:20: `unused` is declared but never used\n"},
+		{
+			input:          "let inner = fn(x) { x / 0 };\nlet outer = fn() { map([1], fn(x) { inner(x) }) };\nouter();",
+			expectedOk:     false,
+			expectedErrOut: "ERROR: test.monkey:1:21: division by zero\n  at inner (called at test.monkey:2:37)\n  at <anonymous>\n  at outer (called at test.monkey:3:1)\n",
+		},
+		{
+			input:          "let inner = fn(x) { x / 0 };\nlet outer = fn() { map([1], fn(x) { inner(x) }) };\nouter();",
+			options:        Options{Engine: util.EngineVM},
+			expectedOk:     false,
+			expectedErrOut: "ERROR: test.monkey:1:21: division by zero\n  at inner (called at test.monkey:2:37)\n  at <anonymous>\n  at outer (called at test.monkey:3:1)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+
+		ok := run("test.monkey", tt.input, tt.options, &out, &errOut)
+		if ok != tt.expectedOk {
+			t.Errorf("wrong result for input `%s`. expected=`%t`, actual=`%t`", tt.input, tt.expectedOk, ok)
+		}
+
+		if out.String() != tt.expectedOut {
+			t.Errorf("wrong output for input `%s`. expected=`%q`, actual=`%q`", tt.input, tt.expectedOut, out.String())
+		}
+
+		if errOut.String() != tt.expectedErrOut {
+			t.Errorf("wrong error output for input `%s`. expected=`%q`, actual=`%q`", tt.input, tt.expectedErrOut, errOut.String())
+		}
+	}
+}
@@ -1,29 +1,71 @@
 package lexer
 
-import "monkey/token"
+import (
+	"monkey/token"
+	"unicode"
+)
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{
 		Type:    tokenType,
 		Literal: lit(ch),
 	}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
-func isDigit(ch byte) bool {
+// isDigit only accepts ASCII digits, since number literals are parsed by `strconv`.
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// skipWhitespace skips whitespace along with `// line` and `/* block */` comments.
 func (l *Lexer) skipWhitespace() {
-	for l.character == ' ' || l.character == '\t' || l.character == '\n' || l.character == '\r' {
+	for {
+		switch {
+		case l.character == ' ' || l.character == '\t' || l.character == '\n' || l.character == '\r':
+			l.readCharacter()
+		case l.character == '/' && l.peekChar() == '/':
+			l.skipLineComment()
+		case l.character == '/' && l.peekChar() == '*':
+			l.skipBlockComment()
+		default:
+			return
+		}
+	}
+}
+
+func (l *Lexer) skipLineComment() {
+	for l.character != '\n' && l.character != 0 {
 		l.readCharacter()
 	}
 }
 
-func lit(ch byte) string {
+// skipBlockComment skips a block comment, which doesn't nest.
+func (l *Lexer) skipBlockComment() {
+	start := l.currentPosition()
+	l.readCharacter()
+	l.readCharacter()
+
+	for !(l.character == '*' && l.peekChar() == '/') {
+		if l.character == 0 {
+			l.error(start, "unterminated comment")
+			return
+		}
+		l.readCharacter()
+	}
+
+	l.readCharacter()
+	l.readCharacter()
+}
+
+func lit(ch rune) string {
 	if ch == 0 {
 		return ""
 	}
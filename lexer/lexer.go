@@ -1,31 +1,80 @@
 package lexer
 
-import "monkey/token"
+import (
+	"fmt"
+	"monkey/token"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
 
 type Lexer struct {
+	filename     string
 	input        string
-	position     int
-	readPosition int
-	character    byte
+	position     int  // byte offset of `character`
+	readPosition int  // byte offset of the rune after `character`
+	character    rune // 0 at the end of the input
+
+	// line and column of `character`, the column counting runes
+	line   int
+	column int
+
+	errors []string
+
+	// for every `${` whose `}` is still to come, the number of unclosed `{` inside it
+	interpolations []int
 }
 
 func NewLexer(input string) *Lexer {
+	return NewFileLexer("", input)
+}
+
+// NewFileLexer creates a lexer whose token positions refer to `filename`.
+func NewFileLexer(filename string, input string) *Lexer {
 	l := &Lexer{
-		input: input,
+		filename: filename,
+		input:    input,
+		line:     1,
 	}
 	l.readCharacter()
 	return l
 }
 
 func (l *Lexer) readCharacter() {
+	if l.character == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+
+	width := 1 // past the end of the input, keep advancing one byte at a time
 	if l.readPosition >= len(l.input) {
 		l.character = 0
 	} else {
-		l.character = l.input[l.readPosition]
+		l.character, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
+	l.column += 1
+}
+
+// Errors returns the errors found so far, e.g. unterminated strings, in the order they were found.
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+func (l *Lexer) error(pos token.Position, format string, args ...interface{}) {
+	msg := fmt.Sprintf("%s: %s", pos, fmt.Sprintf(format, args...))
+	l.errors = append(l.errors, msg)
+}
+
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
 }
 
 func (l *Lexer) NextToken() token.Token {
@@ -33,6 +82,8 @@ func (l *Lexer) NextToken() token.Token {
 
 	l.skipWhitespace()
 
+	start := l.currentPosition()
+
 	switch l.character {
 	case '!':
 		if l.peekChar() == '=' {
@@ -70,14 +121,79 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RightBracket, l.character)
 	case ',':
 		tok = newToken(token.Comma, l.character)
+	case ':':
+		tok = newToken(token.Colon, l.character)
+	case '.':
+		if strings.HasPrefix(l.input[l.position:], "...") {
+			l.readCharacter()
+			l.readCharacter()
+			tok = token.Token{
+				Type:    token.Ellipsis,
+				Literal: "...",
+			}
+		} else {
+			tok = newToken(token.Dot, l.character)
+		}
 	case '+':
-		tok = newToken(token.Plus, l.character)
+		if l.peekChar() == '=' {
+			ch := l.character
+			l.readCharacter()
+			peek := l.character
+			tok = token.Token{
+				Type:    token.PlusAssign,
+				Literal: string(ch) + string(peek),
+			}
+		} else {
+			tok = newToken(token.Plus, l.character)
+		}
 	case '-':
-		tok = newToken(token.Minus, l.character)
+		if l.peekChar() == '=' {
+			ch := l.character
+			l.readCharacter()
+			peek := l.character
+			tok = token.Token{
+				Type:    token.MinusAssign,
+				Literal: string(ch) + string(peek),
+			}
+		} else {
+			tok = newToken(token.Minus, l.character)
+		}
 	case '*':
-		tok = newToken(token.Asterisk, l.character)
+		if l.peekChar() == '=' {
+			ch := l.character
+			l.readCharacter()
+			peek := l.character
+			tok = token.Token{
+				Type:    token.AsteriskAssign,
+				Literal: string(ch) + string(peek),
+			}
+		} else {
+			tok = newToken(token.Asterisk, l.character)
+		}
 	case '/':
-		tok = newToken(token.Slash, l.character)
+		if l.peekChar() == '=' {
+			ch := l.character
+			l.readCharacter()
+			peek := l.character
+			tok = token.Token{
+				Type:    token.SlashAssign,
+				Literal: string(ch) + string(peek),
+			}
+		} else {
+			tok = newToken(token.Slash, l.character)
+		}
+	case '%':
+		if l.peekChar() == '=' {
+			ch := l.character
+			l.readCharacter()
+			peek := l.character
+			tok = token.Token{
+				Type:    token.PercentAssign,
+				Literal: string(ch) + string(peek),
+			}
+		} else {
+			tok = newToken(token.Percent, l.character)
+		}
 	case '<':
 		if l.peekChar() == '=' {
 			ch := l.character
@@ -129,25 +245,38 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.Illegal, l.character)
 		}
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1]++
+		}
 		tok = newToken(token.LeftBrace, l.character)
 	case '}':
-		tok = newToken(token.RightBrace, l.character)
+		if n := len(l.interpolations); n > 0 && l.interpolations[n-1] == 0 {
+			// the `}` closes an interpolation, so the string continues
+			l.interpolations = l.interpolations[:n-1]
+			tok = l.readStringToken(token.StringTail, token.StringMiddle)
+		} else {
+			if n > 0 {
+				l.interpolations[n-1]--
+			}
+			tok = newToken(token.RightBrace, l.character)
+		}
 	case 0:
 		tok = newToken(token.Eof, l.character)
 	case '"':
+		tok = l.readStringToken(token.String, token.StringHead)
+	case '`':
 		tok = token.Token{
 			Type: token.String,
 		}
-		tok.Literal = l.readString()
+		tok.Literal = l.readRawString()
 	default:
 		if isLetter(l.character) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdentifier(tok.Literal)
-			return tok
+			return l.positioned(tok, start)
 		} else if isDigit(l.character) {
-			tok.Literal = l.readDigit()
-			tok.Type = token.Integer
-			return tok
+			tok.Literal, tok.Type = l.readNumber()
+			return l.positioned(tok, start)
 		} else {
 			tok = newToken(token.Illegal, l.character)
 		}
@@ -155,6 +284,13 @@ func (l *Lexer) NextToken() token.Token {
 
 	l.readCharacter()
 
+	return l.positioned(tok, start)
+}
+
+// positioned sets the span of `tok`, which starts at `start` and ends at the current character.
+func (l *Lexer) positioned(tok token.Token, start token.Position) token.Token {
+	tok.Pos = start
+	tok.End = l.currentPosition()
 	return tok
 }
 
@@ -176,19 +312,157 @@ func (l *Lexer) readDigit() string {
 	return l.input[startPos:l.position]
 }
 
-func (l *Lexer) peekChar() byte {
+// readNumber reads an integer, or a float when the digits are followed by a fraction (`3.14`) or an exponent (`1e-9`).
+func (l *Lexer) readNumber() (string, token.TokenType) {
+	startPos := l.position
+	tokenType := token.TokenType(token.Integer)
+
+	l.readDigit()
+	if l.character == '.' && isDigit(l.peekChar()) {
+		tokenType = token.Float
+		l.readCharacter()
+		l.readDigit()
+	}
+	if (l.character == 'e' || l.character == 'E') && l.isExponentAhead() {
+		tokenType = token.Float
+		l.readCharacter()
+		if l.character == '+' || l.character == '-' {
+			l.readCharacter()
+		}
+		l.readDigit()
+	}
+
+	return l.input[startPos:l.position], tokenType
+}
+
+// isExponentAhead reports whether the `e` at the current character starts an exponent, i.e. is followed by digits with an optional sign.
+func (l *Lexer) isExponentAhead() bool {
+	next := l.readPosition
+	if next < len(l.input) && (l.input[next] == '+' || l.input[next] == '-') {
+		next++
+	}
+	return next < len(l.input) && isDigit(rune(l.input[next]))
+}
+
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	}
 
-	return l.input[l.readPosition]
+	ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return ch
 }
 
-func (l *Lexer) readString() string {
+// readStringToken reads the string starting after the current `"` or `}`. The token is of type `complete` when
+// the string ends, or `open` when an interpolation starts.
+func (l *Lexer) readStringToken(complete token.TokenType, open token.TokenType) token.Token {
+	literal, interpolated := l.readString()
+	if interpolated {
+		l.interpolations = append(l.interpolations, 0)
+		return token.Token{Type: open, Literal: literal}
+	}
+
+	return token.Token{Type: complete, Literal: literal}
+}
+
+// readString reads a double-quoted string, decoding its escape sequences. It stops either at the closing `"`, or
+// at the `{` of an interpolation, in which case `interpolated` is true.
+func (l *Lexer) readString() (str string, interpolated bool) {
+	start := l.currentPosition()
+	var out strings.Builder
+
+	for {
+		l.readCharacter()
+		switch l.character {
+		case '"':
+			return out.String(), false
+		case 0:
+			l.error(start, "unterminated string")
+			return out.String(), false
+		case '$':
+			if l.peekChar() == '{' {
+				l.readCharacter()
+				return out.String(), true
+			}
+			out.WriteRune(l.character)
+		case '\\':
+			l.readEscapeSequence(&out)
+		default:
+			out.WriteRune(l.character)
+		}
+	}
+}
+
+// readEscapeSequence decodes the escape sequence starting at the current backslash into `out`.
+func (l *Lexer) readEscapeSequence(out *strings.Builder) {
+	start := l.currentPosition()
+
+	switch l.peekChar() {
+	case 'n':
+		out.WriteRune('\n')
+	case 't':
+		out.WriteRune('\t')
+	case '\\':
+		out.WriteRune('\\')
+	case '"':
+		out.WriteRune('"')
+	case '$':
+		out.WriteRune('$')
+	case 'u':
+		l.readCharacter()
+		l.readUnicodeEscape(out, start)
+		return
+	case 0:
+		// reported as an unterminated string
+		return
+	default:
+		l.error(start, "unknown escape sequence: \\%c", l.peekChar())
+		return
+	}
+
+	l.readCharacter()
+}
+
+// readUnicodeEscape decodes the `{...}` of a `\u{...}` escape, whose hex digits are a code point.
+func (l *Lexer) readUnicodeEscape(out *strings.Builder, start token.Position) {
+	if l.peekChar() != '{' {
+		l.error(start, "invalid unicode escape: expected `{` after \\u")
+		return
+	}
+	l.readCharacter()
+
+	var digits strings.Builder
+	for isHexDigit(l.peekChar()) {
+		l.readCharacter()
+		digits.WriteRune(l.character)
+	}
+
+	if l.peekChar() != '}' {
+		l.error(start, "invalid unicode escape: expected `}` after \\u{%s", digits.String())
+		return
+	}
+	l.readCharacter()
+
+	codePoint, err := strconv.ParseUint(digits.String(), 16, 32)
+	if err != nil || !utf8.ValidRune(rune(codePoint)) {
+		l.error(start, "invalid unicode escape: \\u{%s}", digits.String())
+		return
+	}
+
+	out.WriteRune(rune(codePoint))
+}
+
+// readRawString reads a backtick string, which can span lines and has no escape sequences.
+func (l *Lexer) readRawString() string {
+	start := l.currentPosition()
 	startPos := l.position + 1
 	for {
 		l.readCharacter()
-		if l.character == '"' || l.character == 0 {
+		if l.character == '`' {
+			break
+		}
+		if l.character == 0 {
+			l.error(start, "unterminated raw string")
 			break
 		}
 	}
@@ -232,3 +232,353 @@ func TestNextToken_Brackets(t *testing.T) {
 
 	testLexer(t, input, tests)
 }
+
+type PositionTest struct {
+	expectedLiteral string
+	expectedPos     token.Position
+	expectedEnd     token.Position
+}
+
+func TestNextToken_Positions(t *testing.T) {
+	input := "let x = 10;\n  x != \"ab\""
+
+	tests := []PositionTest{
+		{expectedLiteral: "let", expectedPos: token.Position{Filename: "test.monkey", Offset: 0, Line: 1, Column: 1}, expectedEnd: token.Position{Filename: "test.monkey", Offset: 3, Line: 1, Column: 4}},
+		{expectedLiteral: "x", expectedPos: token.Position{Filename: "test.monkey", Offset: 4, Line: 1, Column: 5}, expectedEnd: token.Position{Filename: "test.monkey", Offset: 5, Line: 1, Column: 6}},
+		{expectedLiteral: "=", expectedPos: token.Position{Filename: "test.monkey", Offset: 6, Line: 1, Column: 7}, expectedEnd: token.Position{Filename: "test.monkey", Offset: 7, Line: 1, Column: 8}},
+		{expectedLiteral: "10", expectedPos: token.Position{Filename: "test.monkey", Offset: 8, Line: 1, Column: 9}, expectedEnd: token.Position{Filename: "test.monkey", Offset: 10, Line: 1, Column: 11}},
+		{expectedLiteral: ";", expectedPos: token.Position{Filename: "test.monkey", Offset: 10, Line: 1, Column: 11}, expectedEnd: token.Position{Filename: "test.monkey", Offset: 11, Line: 1, Column: 12}},
+		{expectedLiteral: "x", expectedPos: token.Position{Filename: "test.monkey", Offset: 14, Line: 2, Column: 3}, expectedEnd: token.Position{Filename: "test.monkey", Offset: 15, Line: 2, Column: 4}},
+		{expectedLiteral: "!=", expectedPos: token.Position{Filename: "test.monkey", Offset: 16, Line: 2, Column: 5}, expectedEnd: token.Position{Filename: "test.monkey", Offset: 18, Line: 2, Column: 7}},
+		{expectedLiteral: "ab", expectedPos: token.Position{Filename: "test.monkey", Offset: 19, Line: 2, Column: 8}, expectedEnd: token.Position{Filename: "test.monkey", Offset: 23, Line: 2, Column: 12}},
+	}
+
+	l := NewFileLexer("test.monkey", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong literal. expected = %q, got = %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos != tt.expectedPos {
+			t.Fatalf("tests[%d] - wrong position. expected = %+v, got = %+v", i, tt.expectedPos, tok.Pos)
+		}
+
+		if tok.End != tt.expectedEnd {
+			t.Fatalf("tests[%d] - wrong end position. expected = %+v, got = %+v", i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestNextToken_Ellipsis(t *testing.T) {
+	input := `fn(a, ...rest) .`
+
+	tests := []token.Token{
+		{Type: token.Function, Literal: "fn"},
+		{Type: token.LeftParenthesis, Literal: "("},
+		{Type: token.Identifier, Literal: "a"},
+		{Type: token.Comma, Literal: ","},
+		{Type: token.Ellipsis, Literal: "..."},
+		{Type: token.Identifier, Literal: "rest"},
+		{Type: token.RightParenthesis, Literal: ")"},
+		{Type: token.Dot, Literal: "."},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_Hash(t *testing.T) {
+	input := `{"foo": "bar", 1: true}`
+
+	tests := []token.Token{
+		{Type: token.LeftBrace, Literal: "{"},
+		{Type: token.String, Literal: "foo"},
+		{Type: token.Colon, Literal: ":"},
+		{Type: token.String, Literal: "bar"},
+		{Type: token.Comma, Literal: ","},
+		{Type: token.Integer, Literal: "1"},
+		{Type: token.Colon, Literal: ":"},
+		{Type: token.True, Literal: "true"},
+		{Type: token.RightBrace, Literal: "}"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_LoopKeywords(t *testing.T) {
+	input := `while for in break continue`
+
+	tests := []token.Token{
+		{Type: token.While, Literal: "while"},
+		{Type: token.For, Literal: "for"},
+		{Type: token.In, Literal: "in"},
+		{Type: token.Break, Literal: "break"},
+		{Type: token.Continue, Literal: "continue"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_ErrorKeywords(t *testing.T) {
+	input := `throw try catch finally`
+
+	tests := []token.Token{
+		{Type: token.Throw, Literal: "throw"},
+		{Type: token.Try, Literal: "try"},
+		{Type: token.Catch, Literal: "catch"},
+		{Type: token.Finally, Literal: "finally"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_Import(t *testing.T) {
+	input := `let lib = import "lib.monkey"; lib.add(1.5)`
+
+	tests := []token.Token{
+		{Type: token.Let, Literal: "let"},
+		{Type: token.Identifier, Literal: "lib"},
+		{Type: token.Assign, Literal: "="},
+		{Type: token.Import, Literal: "import"},
+		{Type: token.String, Literal: "lib.monkey"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Identifier, Literal: "lib"},
+		{Type: token.Dot, Literal: "."},
+		{Type: token.Identifier, Literal: "add"},
+		{Type: token.LeftParenthesis, Literal: "("},
+		{Type: token.Float, Literal: "1.5"},
+		{Type: token.RightParenthesis, Literal: ")"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_AssignmentOperators(t *testing.T) {
+	input := `x += 1; x -= 2; x *= 3; x /= 4;`
+
+	tests := []token.Token{
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.PlusAssign, Literal: "+="},
+		{Type: token.Integer, Literal: "1"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.MinusAssign, Literal: "-="},
+		{Type: token.Integer, Literal: "2"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.AsteriskAssign, Literal: "*="},
+		{Type: token.Integer, Literal: "3"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.SlashAssign, Literal: "/="},
+		{Type: token.Integer, Literal: "4"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_Modulo(t *testing.T) {
+	input := `7 % 3; x %= 2;`
+
+	tests := []token.Token{
+		{Type: token.Integer, Literal: "7"},
+		{Type: token.Percent, Literal: "%"},
+		{Type: token.Integer, Literal: "3"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.PercentAssign, Literal: "%="},
+		{Type: token.Integer, Literal: "2"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_Float(t *testing.T) {
+	input := `3.14 1e-9 2.5E+3 7e2 10 1.x 2e`
+
+	tests := []token.Token{
+		{Type: token.Float, Literal: "3.14"},
+		{Type: token.Float, Literal: "1e-9"},
+		{Type: token.Float, Literal: "2.5E+3"},
+		{Type: token.Float, Literal: "7e2"},
+		{Type: token.Integer, Literal: "10"},
+		{Type: token.Integer, Literal: "1"},
+		{Type: token.Dot, Literal: "."},
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.Integer, Literal: "2"},
+		{Type: token.Identifier, Literal: "e"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_Unicode(t *testing.T) {
+	input := `let größe = "héllo, 世界"; π_2 ≠`
+
+	tests := []token.Token{
+		{Type: token.Let, Literal: "let"},
+		{Type: token.Identifier, Literal: "größe"},
+		{Type: token.Assign, Literal: "="},
+		{Type: token.String, Literal: "héllo, 世界"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Identifier, Literal: "π_"},
+		{Type: token.Integer, Literal: "2"},
+		{Type: token.Illegal, Literal: "≠"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_UnicodePositions(t *testing.T) {
+	input := `"日本" ü`
+
+	tests := []PositionTest{
+		{expectedLiteral: "日本", expectedPos: token.Position{Offset: 0, Line: 1, Column: 1}, expectedEnd: token.Position{Offset: 8, Line: 1, Column: 5}},
+		{expectedLiteral: "ü", expectedPos: token.Position{Offset: 9, Line: 1, Column: 6}, expectedEnd: token.Position{Offset: 11, Line: 1, Column: 7}},
+	}
+
+	l := NewLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong literal. expected = %q, got = %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos != tt.expectedPos {
+			t.Fatalf("tests[%d] - wrong position. expected = %+v, got = %+v", i, tt.expectedPos, tok.Pos)
+		}
+
+		if tok.End != tt.expectedEnd {
+			t.Fatalf("tests[%d] - wrong end position. expected = %+v, got = %+v", i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestNextToken_StringEscapes(t *testing.T) {
+	input := `"a\"b" "line\nnext" "tab\tbed" "back\\slash" "\u{48}\u{e9}\u{1F600}"`
+
+	tests := []token.Token{
+		{Type: token.String, Literal: `a"b`},
+		{Type: token.String, Literal: "line\nnext"},
+		{Type: token.String, Literal: "tab\tbed"},
+		{Type: token.String, Literal: `back\slash`},
+		{Type: token.String, Literal: "Hé😀"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_RawString(t *testing.T) {
+	input := "`raw \\n \"quoted\"\nsecond line` 1"
+
+	tests := []token.Token{
+		{Type: token.String, Literal: "raw \\n \"quoted\"\nsecond line"},
+		{Type: token.Integer, Literal: "1"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{input: `"ok" "\"`, expectedErrors: []string{"1:6: unterminated string"}},
+		{input: "x = `a\nb", expectedErrors: []string{"1:5: unterminated raw string"}},
+		{input: `"a\qb"`, expectedErrors: []string{`1:3: unknown escape sequence: \q`}},
+		{input: `"\u41"`, expectedErrors: []string{"1:2: invalid unicode escape: expected `{` after \\u"}},
+		{input: `"\u{41"`, expectedErrors: []string{"1:2: invalid unicode escape: expected `}` after \\u{41"}},
+		{input: `"\u{110000}"`, expectedErrors: []string{`1:2: invalid unicode escape: \u{110000}`}},
+		{input: `"\u{}"`, expectedErrors: []string{`1:2: invalid unicode escape: \u{}`}},
+		{input: "1 /* a\n * b", expectedErrors: []string{"1:3: unterminated comment"}},
+		{input: "1 /*/ 2", expectedErrors: []string{"1:3: unterminated comment"}},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		for tok := l.NextToken(); tok.Type != token.Eof; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("wrong number of errors for input `%s`. expected=`%q`, actual=`%q`", tt.input, tt.expectedErrors, errors)
+			continue
+		}
+
+		for i, expected := range tt.expectedErrors {
+			if errors[i] != expected {
+				t.Errorf("wrong error for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, errors[i])
+			}
+		}
+	}
+}
+
+func TestNextToken_Comments(t *testing.T) {
+	input := `// leading comment
+let x = 10; // trailing comment
+/* block
+   comment */ x /= /**/ 2;
+"// not a comment" /* a */ /* b */
+// last line`
+
+	tests := []token.Token{
+		{Type: token.Let, Literal: "let"},
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.Assign, Literal: "="},
+		{Type: token.Integer, Literal: "10"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.SlashAssign, Literal: "/="},
+		{Type: token.Integer, Literal: "2"},
+		{Type: token.Semicolon, Literal: ";"},
+		{Type: token.String, Literal: "// not a comment"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
+
+func TestNextToken_Interpolation(t *testing.T) {
+	input := `"a ${x} b ${f({"k": "${y}"}["k"])} \${c}"`
+
+	tests := []token.Token{
+		{Type: token.StringHead, Literal: "a "},
+		{Type: token.Identifier, Literal: "x"},
+		{Type: token.StringMiddle, Literal: " b "},
+		{Type: token.Identifier, Literal: "f"},
+		{Type: token.LeftParenthesis, Literal: "("},
+		{Type: token.LeftBrace, Literal: "{"},
+		{Type: token.String, Literal: "k"},
+		{Type: token.Colon, Literal: ":"},
+		{Type: token.StringHead, Literal: ""},
+		{Type: token.Identifier, Literal: "y"},
+		{Type: token.StringTail, Literal: ""},
+		{Type: token.RightBrace, Literal: "}"},
+		{Type: token.LeftBracket, Literal: "["},
+		{Type: token.String, Literal: "k"},
+		{Type: token.RightBracket, Literal: "]"},
+		{Type: token.RightParenthesis, Literal: ")"},
+		{Type: token.StringTail, Literal: " ${c}"},
+		{Type: token.Eof, Literal: ""},
+	}
+
+	testLexer(t, input, tests)
+}
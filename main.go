@@ -5,6 +5,7 @@ import (
 	"github.com/akamensky/argparse"
 	"monkey/file"
 	"monkey/repl"
+	"monkey/util"
 	"os"
 )
 
@@ -16,6 +17,9 @@ const (
 func main() {
 	argparser := argparse.NewParser("monkey", "Monkey Language")
 	f := argparser.File("i", "input-file", os.O_RDONLY, 0444, &argparse.Options{Required: false, Help: "Source code of monkey language. It must be *.monkey"})
+	engine := argparser.Selector("", "engine", []string{util.EngineEval, util.EngineVM}, &argparse.Options{Required: false, Default: util.EngineEval, Help: "Engine that runs the program: `eval` (tree-walking evaluator) or `vm` (bytecode virtual machine)"})
+	checkedArithmetic := argparser.Flag("", "checked-arithmetic", &argparse.Options{Required: false, Help: "Make integer overflow a runtime error instead of wrapping around"})
+	dumpAST := argparser.Flag("", "dump-ast", &argparse.Options{Required: false, Help: "Print the parsed program of the input file instead of running it"})
 
 	err := argparser.Parse(os.Args)
 	if err != nil {
@@ -24,13 +28,13 @@ func main() {
 	}
 
 	if argparser.GetArgs()[InputFileName].GetParsed() {
-		fs := file.Start(f)
+		fs := file.Start(f, file.Options{DumpAST: *dumpAST, Engine: *engine, CheckedArithmetic: *checkedArithmetic})
 		if !fs {
 			os.Exit(1)
 		}
 		os.Exit(0)
 	}
 
-	repl.Start()
+	repl.Start(repl.Options{Engine: *engine, CheckedArithmetic: *checkedArithmetic})
 	os.Exit(0)
 }
@@ -0,0 +1,146 @@
+package monkey
+
+import (
+	"fmt"
+	"math"
+	"monkey/evaluator"
+	"monkey/object"
+	"reflect"
+	"sort"
+)
+
+// ToObject converts a Go value to a Monkey value:
+//   - nil becomes null
+//   - bool, string and every integer type become booleans, strings and integers
+//   - float32 and float64 become floats
+//   - slices and arrays become arrays
+//   - maps with boolean, string or integer keys become hashes, with the keys in sorted order
+//   - `BuiltinFunc` values become built-in functions
+//   - `object.Object` values are kept as they are
+func ToObject(value interface{}) (object.Object, error) {
+	switch value := value.(type) {
+	case nil:
+		return evaluator.Null, nil
+	case object.Object:
+		return value, nil
+	case BuiltinFunc:
+		return newBuiltIn("built-in function", value), nil
+	case func(args ...interface{}) (interface{}, error):
+		return newBuiltIn("built-in function", value), nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		return evaluator.BooleanObject(v.Bool()), nil
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("integer %d overflows INTEGER", v.Uint())
+		}
+		return &object.Integer{Value: int64(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			element, err := ToObject(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return &object.Array{Elements: elements}, nil
+	case reflect.Map:
+		return mapToHash(v)
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return evaluator.Null, nil
+		}
+		return ToObject(v.Elem().Interface())
+	default:
+		return nil, fmt.Errorf("unsupported Go type %T", value)
+	}
+}
+
+func mapToHash(v reflect.Value) (object.Object, error) {
+	type pair struct {
+		key   object.Hashable
+		value object.Object
+	}
+
+	pairs := []pair{}
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := ToObject(iter.Key().Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+		}
+
+		value, err := ToObject(iter.Value().Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		pairs = append(pairs, pair{key: hashKey, value: value})
+	}
+
+	// Go maps have no order, so the keys are sorted to make the order of the hash predictable
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i].key, pairs[j].key
+		if a.Type() != b.Type() {
+			return a.Type() < b.Type()
+		}
+		return a.Inspect() < b.Inspect()
+	})
+
+	hash := object.NewHash()
+	for _, pair := range pairs {
+		hash.Set(pair.key, pair.value)
+	}
+
+	return hash, nil
+}
+
+// FromObject converts a Monkey value to a Go value:
+//   - null becomes nil
+//   - booleans, strings, integers and floats become bool, string, int64 and float64
+//   - arrays become []interface{}
+//   - hashes become map[interface{}]interface{}
+//   - anything else, e.g. a function, is returned as the `object.Object` itself, so it can be passed back to Monkey
+func FromObject(obj object.Object) interface{} {
+	switch obj := obj.(type) {
+	case nil, *object.Null:
+		return nil
+	case *object.Boolean:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i, element := range obj.Elements {
+			elements[i] = FromObject(element)
+		}
+		return elements
+	case *object.Hash:
+		hash := make(map[interface{}]interface{}, obj.Len())
+		for _, pair := range obj.Pairs() {
+			hash[FromObject(pair.Key)] = FromObject(pair.Value)
+		}
+		return hash
+	default:
+		return obj
+	}
+}
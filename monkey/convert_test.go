@@ -0,0 +1,100 @@
+package monkey
+
+import (
+	"math"
+	"monkey/evaluator"
+	"monkey/object"
+	"reflect"
+	"testing"
+)
+
+func TestToObject(t *testing.T) {
+	n := 7
+	var nilPointer *int
+
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{input: nil, expected: "null"},
+		{input: true, expected: "true"},
+		{input: "abc", expected: "abc"},
+		{input: 5, expected: "5"},
+		{input: int8(-5), expected: "-5"},
+		{input: uint32(5), expected: "5"},
+		{input: 2.5, expected: "2.5"},
+		{input: float32(2), expected: "2.0"},
+		{input: &n, expected: "7"},
+		{input: nilPointer, expected: "null"},
+		{input: []int{1, 2}, expected: "[1, 2]"},
+		{input: [2]string{"a", "b"}, expected: "[a, b]"},
+		{input: []interface{}{1, "a", nil, []bool{true}}, expected: "[1, a, null, [true]]"},
+		{input: map[string]int{"b": 2, "a": 1}, expected: "{a: 1, b: 2}"},
+		{input: map[interface{}]int{"a": 1, 2: 2, true: 3}, expected: "{true: 3, 2: 2, a: 1}"},
+		{input: &object.Integer{Value: 3}, expected: "3"},
+	}
+
+	for _, tt := range tests {
+		actual, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("error for input `%#v`: %s", tt.input, err)
+			continue
+		}
+
+		if actual.Inspect() != tt.expected {
+			t.Errorf("wrong object for input `%#v`. expected=`%s`, actual=`%s`", tt.input, tt.expected, actual.Inspect())
+		}
+	}
+}
+
+func TestToObjectErrors(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{input: uint64(math.MaxUint64), expected: "integer 18446744073709551615 overflows INTEGER"},
+		{input: struct{}{}, expected: "unsupported Go type struct {}"},
+		{input: []interface{}{1, struct{}{}}, expected: "unsupported Go type struct {}"},
+		{input: map[[1]int]int{{1}: 1}, expected: "unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToObject(tt.input)
+		if err == nil {
+			t.Errorf("no error for input `%#v`", tt.input)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for input `%#v`. expected=`%s`, actual=`%s`", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	hash := object.NewHash()
+	hash.Set(&object.String{Value: "a"}, &object.Integer{Value: 1})
+	function := &object.Function{}
+
+	tests := []struct {
+		input    object.Object
+		expected interface{}
+	}{
+		{input: evaluator.Null, expected: nil},
+		{input: evaluator.True, expected: true},
+		{input: &object.Integer{Value: 1}, expected: int64(1)},
+		{input: &object.Float{Value: 0.5}, expected: 0.5},
+		{input: &object.String{Value: "a"}, expected: "a"},
+		{input: &object.Array{Elements: []object.Object{evaluator.Null, &object.Integer{Value: 1}}}, expected: []interface{}{nil, int64(1)}},
+		{input: hash, expected: map[interface{}]interface{}{"a": int64(1)}},
+		{input: function, expected: function},
+	}
+
+	for _, tt := range tests {
+		actual := FromObject(tt.input)
+
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wrong value for input `%s`. expected=`%#v`, actual=`%#v`", tt.input.Inspect(), tt.expected, actual)
+		}
+	}
+}
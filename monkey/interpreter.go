@@ -0,0 +1,157 @@
+// Package monkey embeds the Monkey interpreter into Go programs.
+//
+//	interp := monkey.New()
+//	interp.SetGlobal("limit", 10)
+//	_, err := interp.Run(`let allowed = fn(amount) { amount < limit };`)
+//	allowed, err := interp.Call("allowed", 5) // true
+//
+// Values are converted between Go and Monkey automatically, see `ToObject` and `FromObject`.
+package monkey
+
+import (
+	"fmt"
+	"io"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+)
+
+// BuiltinFunc is a Go function callable from Monkey. Its arguments and result are converted like `SetGlobal` values,
+// and a returned error becomes a Monkey runtime error.
+type BuiltinFunc func(args ...interface{}) (interface{}, error)
+
+// Interpreter evaluates Monkey code. The globals and macros of every `Run` are kept, so later runs and calls can use
+// them.
+type Interpreter struct {
+	evaluator *evaluator.Evaluator
+	env       *object.Environment
+	macroEnv  *object.Environment
+}
+
+func New() *Interpreter {
+	return &Interpreter{
+		evaluator: evaluator.New(),
+		env:       object.NewEnvironment(),
+		macroEnv:  object.NewEnvironment(),
+	}
+}
+
+// SetCheckedArithmetic makes an integer overflow a runtime error, instead of wrapping around.
+func (i *Interpreter) SetCheckedArithmetic(checked bool) {
+	i.evaluator.CheckedArithmetic = checked
+}
+
+// SetOutput makes `puts`, `print` and `printf` write to `w` instead of os.Stdout.
+func (i *Interpreter) SetOutput(w io.Writer) {
+	i.evaluator.Out = w
+}
+
+// Run evaluates `source`, and returns the value of its last statement converted to Go.
+func (i *Interpreter) Run(source string) (interface{}, error) {
+	l := lexer.NewLexer(source)
+	p := parser.NewParser(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return nil, &ParseError{Messages: p.Errors()}
+	}
+
+	evaluator.DefineMacros(program, i.macroEnv)
+	expanded, errObj := i.evaluator.ExpandMacros(program, i.macroEnv)
+	if errObj != nil {
+		return nil, newRuntimeError(errObj)
+	}
+
+	return i.result(i.evaluator.Eval(expanded, i.env))
+}
+
+// Call calls the Monkey function bound to `fnName` with `args` converted to Monkey values.
+func (i *Interpreter) Call(fnName string, args ...interface{}) (interface{}, error) {
+	fn, ok := i.env.Get(fnName)
+	if !ok {
+		return nil, fmt.Errorf("identifier not found: %s", fnName)
+	}
+
+	objects := make([]object.Object, len(args))
+	for index, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d of `%s`: %w", index, fnName, err)
+		}
+		objects[index] = obj
+	}
+
+	return i.result(i.evaluator.ApplyFunction(fn, objects))
+}
+
+// SetGlobal binds `name` to `value` converted to a Monkey value.
+func (i *Interpreter) SetGlobal(name string, value interface{}) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return err
+	}
+
+	i.env.Set(name, obj)
+	return nil
+}
+
+// RegisterBuiltin makes `fn` callable from Monkey as `name`. It takes precedence over a built-in function of the same
+// name.
+func (i *Interpreter) RegisterBuiltin(name string, fn BuiltinFunc) {
+	i.env.Set(name, newBuiltIn(name, fn))
+}
+
+func (i *Interpreter) result(obj object.Object) (interface{}, error) {
+	if errObj, ok := obj.(*object.Error); ok {
+		return nil, newRuntimeError(errObj)
+	}
+
+	return FromObject(obj), nil
+}
+
+func newBuiltIn(name string, fn BuiltinFunc) *object.BuiltIn {
+	return &object.BuiltIn{
+		Fn: func(_ object.Runtime, args ...object.Object) object.Object {
+			values := make([]interface{}, len(args))
+			for i, arg := range args {
+				values[i] = FromObject(arg)
+			}
+
+			result, err := fn(values...)
+			if err != nil {
+				return &object.Error{Message: fmt.Sprintf("%s: %s", name, err)}
+			}
+
+			obj, err := ToObject(result)
+			if err != nil {
+				return &object.Error{Message: fmt.Sprintf("result of `%s`: %s", name, err)}
+			}
+
+			return obj
+		},
+	}
+}
+
+// ParseError holds the syntax errors of the source given to `Run`.
+type ParseError struct {
+	Messages []string
+}
+
+func (e *ParseError) Error() string {
+	return strings.Join(e.Messages, "\n")
+}
+
+// RuntimeError is an error raised while the Monkey code runs, e.g. a type mismatch.
+type RuntimeError struct {
+	Object *object.Error
+}
+
+func newRuntimeError(errObj *object.Error) *RuntimeError {
+	return &RuntimeError{Object: errObj}
+}
+
+func (e *RuntimeError) Error() string {
+	return strings.TrimPrefix(e.Object.Inspect(), "ERROR: ")
+}
@@ -0,0 +1,236 @@
+package monkey
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "1 + 2", expected: int64(3)},
+		{input: `"a" + "b"`, expected: "ab"},
+		{input: "1 < 2", expected: true},
+		{input: "if (false) { 1 }", expected: nil},
+		{input: "let a = 1;", expected: nil},
+		{input: "[1, [true]]", expected: []interface{}{int64(1), []interface{}{true}}},
+		{input: `{"a": 1, 2: "b"}`, expected: map[interface{}]interface{}{"a": int64(1), int64(2): "b"}},
+		{input: "let m = macro(x) { quote(unquote(x) * 2) }; m(4)", expected: int64(8)},
+	}
+
+	for _, tt := range tests {
+		actual, err := New().Run(tt.input)
+		if err != nil {
+			t.Errorf("error for input `%s`: %s", tt.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wrong result for input `%s`. expected=`%#v`, actual=`%#v`", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	_, err := New().Run("let a = ;")
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("wrong error type. expected=`*ParseError`, actual=`%T`", err)
+	}
+
+	if err.Error() != "1:9: no prefix parse function for ; found" {
+		t.Errorf("wrong error message. actual=`%s`", err)
+	}
+
+	_, err = New().Run("let a = 1;\na + true")
+
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("wrong error type. expected=`*RuntimeError`, actual=`%T`", err)
+	}
+
+	if err.Error() != "2:1: type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error message. actual=`%s`", err)
+	}
+
+	if runtimeErr.Object.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong runtimeErr.Object.Message. actual=`%s`", runtimeErr.Object.Message)
+	}
+}
+
+func TestStateIsKeptBetweenRuns(t *testing.T) {
+	interp := New()
+
+	_, err := interp.Run("let add = fn(a, b) { a + b }; let m = macro(x) { quote(add(unquote(x), 1)) };")
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	actual, err := interp.Run("m(2)")
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	if actual != int64(3) {
+		t.Errorf("wrong result. expected=`3`, actual=`%#v`", actual)
+	}
+}
+
+func TestCall(t *testing.T) {
+	interp := New()
+
+	_, err := interp.Run(`
+	let allowed = fn(user, limit) { user["spent"] < limit };
+	let names = fn(users) { [users[0]["name"], users[1]["name"]] };
+	`)
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	actual, err := interp.Call("allowed", map[string]interface{}{"spent": 5}, 10)
+	if err != nil {
+		t.Fatalf("call failed: %s", err)
+	}
+	if actual != true {
+		t.Errorf("wrong result of `allowed`. expected=`true`, actual=`%#v`", actual)
+	}
+
+	users := []map[string]string{{"name": "ann"}, {"name": "bob"}}
+	actual, err = interp.Call("names", users)
+	if err != nil {
+		t.Fatalf("call failed: %s", err)
+	}
+	if !reflect.DeepEqual(actual, []interface{}{"ann", "bob"}) {
+		t.Errorf("wrong result of `names`. actual=`%#v`", actual)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	interp := New()
+
+	_, err := interp.Run("let f = fn(x) { x + 1 }; let n = 1;")
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	tests := []struct {
+		fnName   string
+		args     []interface{}
+		expected string
+	}{
+		{fnName: "missing", expected: "identifier not found: missing"},
+		{fnName: "n", expected: "not a function: INTEGER"},
+		{fnName: "f", args: []interface{}{"a"}, expected: "1:17: type mismatch: STRING + INTEGER"},
+		{fnName: "f", args: []interface{}{struct{}{}}, expected: "argument 0 of `f`: unsupported Go type struct {}"},
+	}
+
+	for _, tt := range tests {
+		_, err := interp.Call(tt.fnName, tt.args...)
+		if err == nil {
+			t.Errorf("no error for call of `%s`", tt.fnName)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for call of `%s`. expected=`%s`, actual=`%s`", tt.fnName, tt.expected, err)
+		}
+	}
+}
+
+func TestSetGlobal(t *testing.T) {
+	interp := New()
+
+	err := interp.SetGlobal("limits", map[string]int{"daily": 100, "monthly": 1000})
+	if err != nil {
+		t.Fatalf("SetGlobal failed: %s", err)
+	}
+
+	actual, err := interp.Run(`limits["daily"] + limits["monthly"]`)
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+	if actual != int64(1100) {
+		t.Errorf("wrong result. expected=`1100`, actual=`%#v`", actual)
+	}
+
+	err = interp.SetGlobal("c", make(chan int))
+	if err == nil || err.Error() != "unsupported Go type chan int" {
+		t.Errorf("wrong error for unsupported value. actual=`%v`", err)
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	interp := New()
+
+	interp.RegisterBuiltin("upper", func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.New("expected 1 argument")
+		}
+
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("expected a string")
+		}
+
+		return strings.ToUpper(s), nil
+	})
+
+	actual, err := interp.Run(`upper("abc") + "!"`)
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+	if actual != "ABC!" {
+		t.Errorf("wrong result. expected=`ABC!`, actual=`%#v`", actual)
+	}
+
+	_, err = interp.Run("upper(1)")
+	if err == nil || err.Error() != "1:1: upper: expected a string" {
+		t.Errorf("wrong error. actual=`%v`", err)
+	}
+
+	// a registered function replaces the built-in function of the same name
+	interp.RegisterBuiltin("len", func(args ...interface{}) (interface{}, error) {
+		return 42, nil
+	})
+
+	actual, err = interp.Run("len([])")
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+	if actual != int64(42) {
+		t.Errorf("wrong result. expected=`42`, actual=`%#v`", actual)
+	}
+}
+
+func TestSetCheckedArithmetic(t *testing.T) {
+	interp := New()
+	interp.SetCheckedArithmetic(true)
+
+	_, err := interp.Run("9223372036854775807 + 1")
+	if err == nil || err.Error() != "1:1: integer overflow: 9223372036854775807 + 1" {
+		t.Errorf("wrong error. actual=`%v`", err)
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	var out bytes.Buffer
+
+	interp := New()
+	interp.SetOutput(&out)
+
+	_, err := interp.Run(`puts("a", [1, 2]); printf("%03d|%-3s|", 7, "x"); print(1, 2)`)
+	if err != nil {
+		t.Fatalf("unexpected error. actual=`%v`", err)
+	}
+
+	expected := "a\n[1, 2]\n007|x  |1 2"
+	if out.String() != expected {
+		t.Errorf("wrong output. expected=`%q`, actual=`%q`", expected, out.String())
+	}
+}
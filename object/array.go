@@ -15,11 +15,20 @@ func (ao *Array) Type() ObjectType {
 	return ArrayObj
 }
 func (ao *Array) Inspect() string {
+	return ao.inspect(map[Object]bool{})
+}
+func (ao *Array) inspect(visiting map[Object]bool) string {
+	if visiting[ao] {
+		return "[...]"
+	}
+	visiting[ao] = true
+	defer delete(visiting, ao)
+
 	var out bytes.Buffer
 
 	elements := []string{}
 	for _, e := range ao.Elements {
-		elements = append(elements, e.Inspect())
+		elements = append(elements, inspectElement(e, visiting))
 	}
 
 	out.WriteString("[")
@@ -28,3 +37,27 @@ func (ao *Array) Inspect() string {
 
 	return out.String()
 }
+func (ao *Array) Equals(other Object) bool {
+	return ao.equals(other, map[[2]Object]bool{})
+}
+func (ao *Array) equals(other Object, compared map[[2]Object]bool) bool {
+	otherArray, ok := other.(*Array)
+	if !ok {
+		return false
+	}
+	if ao == otherArray || compared[[2]Object{ao, otherArray}] {
+		return true
+	}
+	compared[[2]Object{ao, otherArray}] = true
+
+	if len(ao.Elements) != len(otherArray.Elements) {
+		return false
+	}
+	for i, element := range ao.Elements {
+		if !equalElements(element, otherArray.Elements[i], compared) {
+			return false
+		}
+	}
+
+	return true
+}
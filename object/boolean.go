@@ -14,3 +14,15 @@ func (b *Boolean) Type() ObjectType {
 func (b *Boolean) Inspect() string {
 	return fmt.Sprintf("%t", b.Value)
 }
+func (b *Boolean) Equals(other Object) bool {
+	otherBoolean, ok := other.(*Boolean)
+	return ok && b.Value == otherBoolean.Value
+}
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+
+	return HashKey{Type: b.Type(), Value: value}
+}
@@ -1,8 +1,18 @@
 package object
 
+import "io"
+
 const BuiltInObj = "BUILT_IN"
 
-type BuiltInFunction func(args ...Object) Object
+// Runtime is the engine calling a built-in function, which the built-in function can use to call back into Monkey
+// code, e.g. the function passed to `map`.
+type Runtime interface {
+	ApplyFunction(fn Object, args []Object) Object
+	// Output is where built-in functions like `puts` write.
+	Output() io.Writer
+}
+
+type BuiltInFunction func(rt Runtime, args ...Object) Object
 
 type BuiltIn struct {
 	Fn BuiltInFunction
@@ -14,3 +24,6 @@ func (b *BuiltIn) Type() ObjectType {
 func (b *BuiltIn) Inspect() string {
 	return "built-in function"
 }
+func (b *BuiltIn) Equals(other Object) bool {
+	return b == other
+}
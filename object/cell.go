@@ -0,0 +1,19 @@
+package object
+
+const CellObj = "CELL"
+
+// Cell holds a local variable of the vm once a closure captures it, so the function defining the variable and all the
+// closures capturing it see each other's assignments. Cells never reach the user, reading the variable yields `Value`.
+type Cell struct {
+	Value Object
+}
+
+func (c *Cell) Type() ObjectType {
+	return CellObj
+}
+func (c *Cell) Inspect() string {
+	return "cell(" + c.Value.Inspect() + ")"
+}
+func (c *Cell) Equals(other Object) bool {
+	return c == other
+}
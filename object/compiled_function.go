@@ -0,0 +1,84 @@
+package object
+
+import (
+	"fmt"
+	"monkey/code"
+	"monkey/token"
+	"sort"
+)
+
+const CompiledFunctionObj = "COMPILED_FUNCTION"
+
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumLocals     int
+	NumParameters int  // number of parameters, not counting the rest parameter
+	Rest          bool // whether the local after the parameters collects the remaining arguments
+	Name          string
+
+	// Entries are the positions where the function starts, when it is called with the required arguments and 0, 1, ...
+	// default values given. The code before the last entry sets the default values, nil when there are none.
+	Entries []int
+
+	LocalNames []string // names of the locals, indexed by their slot, used in error messages
+	FreeNames  []string // names of the free variables, used in error messages
+
+	Positions []InstructionPosition // where the instructions come from in the source, by increasing offset
+}
+
+// InstructionPosition is the position of the code that the instructions from `Offset` on were compiled from, up to the
+// offset of the next one.
+type InstructionPosition struct {
+	Offset int
+	Pos    token.Position
+}
+
+func (cf *CompiledFunction) Type() ObjectType {
+	return CompiledFunctionObj
+}
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+func (cf *CompiledFunction) Equals(other Object) bool {
+	return cf == other
+}
+
+// DisplayName is the name that error messages show for the function.
+func (cf *CompiledFunction) DisplayName() string {
+	return displayName(cf.Name)
+}
+
+// PosAt returns the position of the code that the instruction at `ip` was compiled from, if it is known.
+func (cf *CompiledFunction) PosAt(ip int) token.Position {
+	i := sort.Search(len(cf.Positions), func(i int) bool { return cf.Positions[i].Offset > ip })
+	if i == 0 {
+		return token.Position{}
+	}
+
+	return cf.Positions[i-1].Pos
+}
+
+// NumRequired returns the number of parameters without a default value.
+func (cf *CompiledFunction) NumRequired() int {
+	if len(cf.Entries) == 0 {
+		return cf.NumParameters
+	}
+	return cf.NumParameters - (len(cf.Entries) - 1)
+}
+
+// Closure is a compiled function together with the free variables it captured when it was created. For the user it is just
+// a function, so it shares the type of `*Function`.
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+func (c *Closure) Type() ObjectType {
+	return FunctionObj
+}
+func (c *Closure) Inspect() string {
+	return fmt.Sprintf("Closure[%p]", c)
+}
+func (c *Closure) Equals(other Object) bool {
+	return c == other
+}
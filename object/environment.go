@@ -1,30 +1,215 @@
 package object
 
+// Environment holds the bindings of a scope in slots. Code that was resolved (see `evaluator.Resolve`) reaches a binding
+// by its depth and slot, while the REPL and any other code that was not resolved looks it up by name. A slot without a
+// value is not bound yet, so a lookup goes on to the outer environments, like for a name that is missing.
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	values []Object
+	names  []string       // the name of each slot, which is shared by the environments of the same function
+	index  map[string]int // the slot of each name, once bindings added to the environment make it large
+	outer  *Environment
 }
 
+// The environment of a function with few bindings, which are most functions, is allocated together with its slots.
+type (
+	environment1 struct {
+		Environment
+		slots [1]Object
+	}
+	environment2 struct {
+		Environment
+		slots [2]Object
+	}
+	environment3 struct {
+		Environment
+		slots [3]Object
+	}
+	environment4 struct {
+		Environment
+		slots [4]Object
+	}
+)
+
+// indexedNames is the number of names from which an environment that gets bindings added keeps an index of its slots,
+// e.g. the global environment of the REPL, instead of scanning its names on every lookup.
+const indexedNames = 16
+
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	return &Environment{}
+}
+
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	return &Environment{outer: outer}
+}
+
+// NewFunctionEnvironment returns an environment with one empty slot for each of `names`, which are the names of a
+// resolved function.
+func NewFunctionEnvironment(outer *Environment, names []string) *Environment {
+	// the capacity is cut, so that a binding added by name copies the names instead of writing into the shared array
+	var env *Environment
+	switch len(names) {
+	case 0:
+		env = &Environment{}
+	case 1:
+		e := &environment1{}
+		env = &e.Environment
+		env.values = e.slots[:]
+	case 2:
+		e := &environment2{}
+		env = &e.Environment
+		env.values = e.slots[:]
+	case 3:
+		e := &environment3{}
+		env = &e.Environment
+		env.values = e.slots[:]
+	case 4:
+		e := &environment4{}
+		env = &e.Environment
+		env.values = e.slots[:]
+	default:
+		env = &Environment{values: make([]Object, len(names))}
+	}
+
+	env.names = names[:len(names):len(names)]
+	env.outer = outer
+	return env
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
-	if !ok && e.outer != nil {
-		obj, ok = e.outer.Get(name)
+	for env := e; env != nil; env = env.outer {
+		if i := env.slot(name); i >= 0 && env.values[i] != nil {
+			return env.values[i], true
+		}
 	}
-	return obj, ok
+
+	return nil, false
 }
 
 func (e *Environment) Set(name string, val Object) Object {
-	e.store[name] = val
+	if i := e.slot(name); i >= 0 {
+		e.values[i] = val
+		return val
+	}
+
+	e.names = append(e.names, name)
+	e.values = append(e.values, val)
+	e.indexFrom(len(e.names) - 1)
 	return val
 }
 
-func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
+// Assign updates the nearest binding of `name`, and reports whether there is one.
+func (e *Environment) Assign(name string, val Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if i := env.slot(name); i >= 0 && env.values[i] != nil {
+			env.values[i] = val
+			return true
+		}
+	}
+
+	return false
+}
+
+// slot returns the slot of `name`, or -1 when there is none.
+func (e *Environment) slot(name string) int {
+	if e.index != nil {
+		if i, ok := e.index[name]; ok {
+			return i
+		}
+		return -1
+	}
+
+	for i := len(e.names) - 1; i >= 0; i-- {
+		if e.names[i] == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Ancestor returns the environment `depth` levels out, which is `e` itself at depth 0.
+func (e *Environment) Ancestor(depth int) *Environment {
+	env := e
+	for i := 0; i < depth && env.outer != nil; i++ {
+		env = env.outer
+	}
 	return env
 }
+
+// GetAt returns the value in `slot` of the environment `depth` levels out. It looks up `name` instead when the slot is
+// empty, e.g. when a `let` in a branch that didn't run would have bound it.
+func (e *Environment) GetAt(depth int, slot int, name string) (Object, bool) {
+	env := e.Ancestor(depth)
+	if slot < len(env.values) && env.values[slot] != nil {
+		return env.values[slot], true
+	}
+
+	return e.Get(name)
+}
+
+// SetAt binds `slot` to `val`. It binds `name` instead when the environment has no such slot, i.e. when the code was
+// resolved for another environment.
+func (e *Environment) SetAt(slot int, name string, val Object) Object {
+	if slot < len(e.values) {
+		e.values[slot] = val
+		return val
+	}
+
+	return e.Set(name, val)
+}
+
+// AssignAt updates the binding in `slot` of the environment `depth` levels out, or the nearest binding of `name` when
+// the slot is empty. It reports whether there is a binding.
+func (e *Environment) AssignAt(depth int, slot int, name string, val Object) bool {
+	env := e.Ancestor(depth)
+	if slot < len(env.values) && env.values[slot] != nil {
+		env.values[slot] = val
+		return true
+	}
+
+	return e.Assign(name, val)
+}
+
+// Names returns the name of each slot.
+func (e *Environment) Names() []string {
+	return e.names
+}
+
+// Declare adds empty slots for `names`, which starts with the names the environment already has.
+func (e *Environment) Declare(names []string) {
+	if len(names) <= len(e.names) {
+		return
+	}
+
+	start := len(e.names)
+	e.values = append(e.values, make([]Object, len(names)-start)...)
+	e.names = append(e.names, names[start:]...)
+	e.indexFrom(start)
+}
+
+// indexFrom adds the names from slot `start` on to the index, which is built once the environment has many names.
+func (e *Environment) indexFrom(start int) {
+	if e.index == nil {
+		if len(e.names) < indexedNames {
+			return
+		}
+		e.index = make(map[string]int, len(e.names))
+		start = 0
+	}
+
+	for i := start; i < len(e.names); i++ {
+		e.index[e.names[i]] = i
+	}
+}
+
+// Bindings returns the bindings of this environment, without the ones of the outer environments.
+func (e *Environment) Bindings() map[string]Object {
+	bindings := make(map[string]Object, len(e.names))
+	for i, name := range e.names {
+		if e.values[i] != nil {
+			bindings[name] = e.values[i]
+		}
+	}
+
+	return bindings
+}
@@ -1,14 +1,54 @@
 package object
 
+import (
+	"monkey/token"
+	"strings"
+)
+
 const ErrorObj = "ERROR"
 
 type Error struct {
 	Message string
+	Pos     token.Position // where the error happened, if known
+	Stack   []StackFrame   // the function calls the error unwound through, innermost first
+}
+
+// StackFrame is a call of a function that was still running when an error happened.
+type StackFrame struct {
+	Function string         // name of the function, or `<anonymous>`
+	Pos      token.Position // position of the call, unknown when a built-in function made it
+}
+
+func (f StackFrame) String() string {
+	if f.Pos.IsValid() {
+		return f.Function + " (called at " + f.Pos.String() + ")"
+	}
+
+	return f.Function
 }
 
 func (e *Error) Type() ObjectType {
 	return ErrorObj
 }
 func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
+
 	return "ERROR: " + e.Message
 }
+func (e *Error) Equals(other Object) bool {
+	return e == other
+}
+
+// Traceback returns the error followed by one line per frame of its stack, innermost first.
+func (e *Error) Traceback() string {
+	var out strings.Builder
+
+	out.WriteString(e.Inspect())
+	for _, frame := range e.Stack {
+		out.WriteString("\n  at " + frame.String())
+	}
+
+	return out.String()
+}
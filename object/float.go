@@ -0,0 +1,44 @@
+package object
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const FloatObj = "FLOAT"
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType {
+	return FloatObj
+}
+
+// Inspect always shows a fraction or an exponent, so `2.0` isn't mistaken for the integer `2`.
+func (f *Float) Inspect() string {
+	str := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if math.IsInf(f.Value, 0) || math.IsNaN(f.Value) || strings.ContainsAny(str, ".e") {
+		return str
+	}
+	return str + ".0"
+}
+func (f *Float) Equals(other Object) bool {
+	switch other := other.(type) {
+	case *Float:
+		return f.Value == other.Value
+	case *Integer:
+		return f.Value == float64(other.Value)
+	default:
+		return false
+	}
+}
+
+// HashKey of a whole number is the key of the equal integer, since `1.0 == 1` must find the same entry.
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && f.Value >= math.MinInt64 && f.Value < -math.MinInt64 {
+		return (&Integer{Value: int64(f.Value)}).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
@@ -10,8 +10,12 @@ const FunctionObj = "FUNCTION"
 
 type Function struct {
 	Parameters []*ast.Identifier
+	Defaults   []ast.Expression // default values of the parameters, like `ast.FunctionLiteral.Defaults`
+	Rest       *ast.Identifier  // the parameter that collects the remaining arguments, nil without one
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Name       string   // name of the let binding that defined the function, empty when it is anonymous
+	Slots      []string // names of the slots of the environment of a call, like `ast.FunctionLiteral.Slots`
 }
 
 func (f *Function) Type() ObjectType {
@@ -21,8 +25,15 @@ func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
+	for i, p := range f.Parameters {
+		if i < len(f.Defaults) && f.Defaults[i] != nil {
+			params = append(params, p.String()+" = "+f.Defaults[i].String())
+		} else {
+			params = append(params, p.String())
+		}
+	}
+	if f.Rest != nil {
+		params = append(params, "..."+f.Rest.String())
 	}
 
 	out.WriteString("fn(")
@@ -33,3 +44,29 @@ func (f *Function) Inspect() string {
 
 	return out.String()
 }
+
+// DisplayName is the name that a stack trace shows for the function.
+func (f *Function) DisplayName() string {
+	return displayName(f.Name)
+}
+
+// NumRequired returns the number of parameters without a default value.
+func (f *Function) NumRequired() int {
+	required := 0
+	for i := range f.Parameters {
+		if i >= len(f.Defaults) || f.Defaults[i] == nil {
+			required++
+		}
+	}
+	return required
+}
+
+func displayName(name string) string {
+	if name == "" {
+		return "<anonymous>"
+	}
+	return name
+}
+func (f *Function) Equals(other Object) bool {
+	return f == other
+}
@@ -0,0 +1,148 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+const HashObj = "HASH"
+
+// HashKey identifies a hash key by value, so two different `*String` objects with the same content map to the same entry.
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+	Text  string // the content of a string key, which is kept whole so that two strings never share an entry
+}
+
+// Hashable is implemented by every object that can be used as a hash key.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	pairs map[HashKey]HashPair
+	order []HashKey // insertion order of the keys
+}
+
+func NewHash() *Hash {
+	return &Hash{
+		pairs: make(map[HashKey]HashPair),
+	}
+}
+
+func (h *Hash) Type() ObjectType {
+	return HashObj
+}
+func (h *Hash) Inspect() string {
+	return h.inspect(map[Object]bool{})
+}
+func (h *Hash) inspect(visiting map[Object]bool) string {
+	if visiting[h] {
+		return "{...}"
+	}
+	visiting[h] = true
+	defer delete(visiting, h)
+
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.Pairs() {
+		pairs = append(pairs, pair.Key.Inspect()+": "+inspectElement(pair.Value, visiting))
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Equals reports whether both hashes have equal values for the same keys, in any order.
+func (h *Hash) Equals(other Object) bool {
+	return h.equals(other, map[[2]Object]bool{})
+}
+func (h *Hash) equals(other Object, compared map[[2]Object]bool) bool {
+	otherHash, ok := other.(*Hash)
+	if !ok {
+		return false
+	}
+	if h == otherHash || compared[[2]Object{h, otherHash}] {
+		return true
+	}
+	compared[[2]Object{h, otherHash}] = true
+
+	if h.Len() != otherHash.Len() {
+		return false
+	}
+	for _, pair := range h.Pairs() {
+		value, ok := otherHash.Get(pair.Key.(Hashable))
+		if !ok || !equalElements(pair.Value, value, compared) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.pairs[key.HashKey()]
+	if !ok {
+		return nil, false
+	}
+
+	return pair.Value, true
+}
+
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.pairs[hashKey]; !ok {
+		h.order = append(h.order, hashKey)
+	}
+
+	h.pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+func (h *Hash) Delete(key Hashable) {
+	hashKey := key.HashKey()
+	if _, ok := h.pairs[hashKey]; !ok {
+		return
+	}
+
+	delete(h.pairs, hashKey)
+	for i, k := range h.order {
+		if k == hashKey {
+			h.order = append(h.order[:i], h.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (h *Hash) Len() int {
+	return len(h.order)
+}
+
+// Pairs returns the key-value pairs in insertion order.
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.order))
+	for _, k := range h.order {
+		pairs = append(pairs, h.pairs[k])
+	}
+
+	return pairs
+}
+
+// Copy returns a shallow copy of the hash.
+func (h *Hash) Copy() *Hash {
+	hash := NewHash()
+	for _, pair := range h.Pairs() {
+		hash.Set(pair.Key.(Hashable), pair.Value)
+	}
+
+	return hash
+}
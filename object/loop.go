@@ -0,0 +1,33 @@
+package object
+
+const (
+	BreakObj    = "BREAK"
+	ContinueObj = "CONTINUE"
+)
+
+// Break is the result of a `break` statement. Like `*ReturnValue`, it travels up through the blocks until it reaches
+// the loop.
+type Break struct{}
+
+func (b *Break) Type() ObjectType {
+	return BreakObj
+}
+func (b *Break) Inspect() string {
+	return "break"
+}
+func (b *Break) Equals(other Object) bool {
+	return b == other
+}
+
+// Continue is the result of a `continue` statement, see `Break`.
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType {
+	return ContinueObj
+}
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+func (c *Continue) Equals(other Object) bool {
+	return c == other
+}
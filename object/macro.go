@@ -0,0 +1,39 @@
+package object
+
+import (
+	"bytes"
+	"monkey/ast"
+	"strings"
+)
+
+const MacroObj = "MACRO"
+
+// Macro is like `*Function`, but it is called with the code of its arguments and returns code, see `Quote`.
+type Macro struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (m *Macro) Type() ObjectType {
+	return MacroObj
+}
+func (m *Macro) Inspect() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range m.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("macro(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(m.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+func (m *Macro) Equals(other Object) bool {
+	return m == other
+}
@@ -0,0 +1,21 @@
+package object
+
+const ModuleObj = "MODULE"
+
+// Module is the result of `import`. Its exports are the top-level bindings of the module, except the ones whose name
+// starts with `_`.
+type Module struct {
+	Name    string
+	Path    string // the resolved path of the module file
+	Exports map[string]Object
+}
+
+func (m *Module) Type() ObjectType {
+	return ModuleObj
+}
+func (m *Module) Inspect() string {
+	return "module " + m.Name
+}
+func (m *Module) Equals(other Object) bool {
+	return m == other
+}
@@ -5,4 +5,36 @@ type ObjectType string
 type Object interface {
 	Type() ObjectType
 	Inspect() string
+
+	// Equals is what `==` means in Monkey. Numbers are equal by value even across INTEGER and FLOAT, so `1 == 1.0`;
+	// booleans, strings and null are equal by value; arrays and hashes are equal when their elements are equal; any
+	// other object, e.g. a function, only equals itself. Objects of different types are never equal otherwise.
+	Equals(other Object) bool
+}
+
+// container is an object that holds other objects, which may hold the container itself, e.g. after `a[0] = a`. Its
+// methods take the containers visited so far, so they don't recurse forever.
+type container interface {
+	inspect(visiting map[Object]bool) string
+	equals(other Object, compared map[[2]Object]bool) bool
+}
+
+// inspectElement returns the `Inspect` string of an element of a container. A container that is already being
+// inspected is shown as `[...]` or `{...}`.
+func inspectElement(o Object, visiting map[Object]bool) string {
+	if c, ok := o.(container); ok {
+		return c.inspect(visiting)
+	}
+
+	return o.Inspect()
+}
+
+// equalElements reports whether two elements of containers are equal. A pair of containers that is already being
+// compared counts as equal, since any difference is found by the comparison that is still going on.
+func equalElements(a Object, b Object, compared map[[2]Object]bool) bool {
+	if c, ok := a.(container); ok {
+		return c.equals(b, compared)
+	}
+
+	return a.Equals(b)
 }
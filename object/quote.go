@@ -0,0 +1,20 @@
+package object
+
+import "monkey/ast"
+
+const QuoteObj = "QUOTE"
+
+// Quote is the result of `quote(...)`: the code of its argument, not evaluated.
+type Quote struct {
+	Node ast.Node
+}
+
+func (q *Quote) Type() ObjectType {
+	return QuoteObj
+}
+func (q *Quote) Inspect() string {
+	return "QUOTE(" + q.Node.String() + ")"
+}
+func (q *Quote) Equals(other Object) bool {
+	return q == other
+}
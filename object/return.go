@@ -12,3 +12,6 @@ func (rv *ReturnValue) Type() ObjectType {
 func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
+func (rv *ReturnValue) Equals(other Object) bool {
+	return rv == other
+}
@@ -12,3 +12,10 @@ func (s *String) Type() ObjectType {
 func (s *String) Inspect() string {
 	return s.Value
 }
+func (s *String) Equals(other Object) bool {
+	otherString, ok := other.(*String)
+	return ok && s.Value == otherString.Value
+}
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Text: s.Value}
+}
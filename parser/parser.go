@@ -5,37 +5,49 @@ import (
 	"monkey/ast"
 	"monkey/lexer"
 	"monkey/token"
+	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 const (
 	_ int = iota
 	Lowest
+	Assign        // e.g. x = 5 or x += 5
+	Or            // e.g. a || b
+	And           // e.g. a && b
 	Equal         // e.g. 1 == a
 	LessOrGreater // e.g. 2 < 3 or 3 > 1
-	Boolean       // e.g. a && b or c || d
 	Sum           // e.g. 2 + 4
-	Product       // e.g. 5 * 3
+	Product       // e.g. 5 * 3 or 5 % 3
 	Prefix        // e.g. -5
 	Call          // e.g. add(2, 3)
 	Index         // e.g. myArray[5]
 )
 
 var precedences = map[token.TokenType]int{
+	token.Assign:             Assign,
+	token.PlusAssign:         Assign,
+	token.MinusAssign:        Assign,
+	token.AsteriskAssign:     Assign,
+	token.SlashAssign:        Assign,
+	token.PercentAssign:      Assign,
 	token.Equal:              Equal,
 	token.NotEqual:           Equal,
 	token.LessThan:           LessOrGreater,
 	token.GreaterThan:        LessOrGreater,
 	token.LessThanOrEqual:    LessOrGreater,
 	token.GreaterThanOrEqual: LessOrGreater,
-	token.BooleanAnd:         Boolean,
-	token.BooleanOr:          Boolean,
+	token.BooleanAnd:         And,
+	token.BooleanOr:          Or,
 	token.Plus:               Sum,
 	token.Minus:              Sum,
 	token.Slash:              Product,
 	token.Asterisk:           Product,
+	token.Percent:            Product,
 	token.LeftParenthesis:    Call,
 	token.LeftBracket:        Index,
+	token.Dot:                Index,
 }
 
 type (
@@ -52,6 +64,9 @@ type Parser struct {
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	// number of loops around the current token, within the current function
+	loopDepth int
 }
 
 func NewParser(l *lexer.Lexer) *Parser {
@@ -63,21 +78,28 @@ func NewParser(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.Identifier, p.parseIdentifier)
 	p.registerPrefix(token.Integer, p.parseIntegerLiteral)
+	p.registerPrefix(token.Float, p.parseFloatLiteral)
+	p.registerPrefix(token.StringHead, p.parseInterpolatedString)
 	p.registerPrefix(token.Bang, p.parsePrefixExpression)
 	p.registerPrefix(token.Minus, p.parsePrefixExpression)
 	p.registerPrefix(token.True, p.parseBoolean)
 	p.registerPrefix(token.False, p.parseBoolean)
 	p.registerPrefix(token.LeftParenthesis, p.parseGroupedExpression)
 	p.registerPrefix(token.If, p.parseIfExpression)
+	p.registerPrefix(token.Try, p.parseTryExpression)
+	p.registerPrefix(token.Import, p.parseImportExpression)
 	p.registerPrefix(token.Function, p.parseFunctionLiteral)
+	p.registerPrefix(token.Macro, p.parseMacroLiteral)
 	p.registerPrefix(token.String, p.parseStringLiteral)
 	p.registerPrefix(token.LeftBracket, p.parseArrayLiteral)
+	p.registerPrefix(token.LeftBrace, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.Plus, p.parseInfixExpression)
 	p.registerInfix(token.Minus, p.parseInfixExpression)
 	p.registerInfix(token.Slash, p.parseInfixExpression)
 	p.registerInfix(token.Asterisk, p.parseInfixExpression)
+	p.registerInfix(token.Percent, p.parseInfixExpression)
 	p.registerInfix(token.Equal, p.parseInfixExpression)
 	p.registerInfix(token.NotEqual, p.parseInfixExpression)
 	p.registerInfix(token.LessThan, p.parseInfixExpression)
@@ -86,8 +108,15 @@ func NewParser(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GreaterThanOrEqual, p.parseInfixExpression)
 	p.registerInfix(token.BooleanAnd, p.parseInfixExpression)
 	p.registerInfix(token.BooleanOr, p.parseInfixExpression)
+	p.registerInfix(token.Assign, p.parseAssignExpression)
+	p.registerInfix(token.PlusAssign, p.parseAssignExpression)
+	p.registerInfix(token.MinusAssign, p.parseAssignExpression)
+	p.registerInfix(token.AsteriskAssign, p.parseAssignExpression)
+	p.registerInfix(token.SlashAssign, p.parseAssignExpression)
+	p.registerInfix(token.PercentAssign, p.parseAssignExpression)
 	p.registerInfix(token.LeftParenthesis, p.parseCallExpression)
 	p.registerInfix(token.LeftBracket, p.parseIndexExpression)
+	p.registerInfix(token.Dot, p.parseMemberExpression)
 
 	// read 2 tokens, so that current and peek (next) token are set
 	p.nextToken()
@@ -110,7 +139,24 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.current.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.current.Literal)
+		msg := fmt.Sprintf("%s: could not parse %q as integer", p.current.Pos, p.current.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	literal.Value = value
+
+	return literal
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{
+		Token: p.current,
+	}
+
+	value, err := strconv.ParseFloat(p.current.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("%s: could not parse %q as float", p.current.Pos, p.current.Literal)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
@@ -135,7 +181,11 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 
 func (p *Parser) nextToken() {
 	p.current = p.peek
+
+	// lexer errors, e.g. unterminated strings, are reported along with the parser's own errors
+	lexerErrors := len(p.lexer.Errors())
 	p.peek = p.lexer.NextToken()
+	p.errors = append(p.errors, p.lexer.Errors()[lexerErrors:]...)
 }
 
 func (p *Parser) Errors() []string {
@@ -171,6 +221,18 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.Return:
 		return p.parseReturnStatement()
+	case token.While:
+		return p.parseWhileStatement()
+	case token.For:
+		return p.parseForStatement()
+	case token.Break:
+		return p.parseBreakStatement()
+	case token.Continue:
+		return p.parseContinueStatement()
+	case token.Throw:
+		return p.parseThrowStatement()
+	case token.Import:
+		return p.parseImportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -198,6 +260,10 @@ func (p *Parser) parseLetStatement() ast.Statement {
 
 	stmt.Value = p.parseExpression(Lowest)
 
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
+
 	if p.peek.Type == token.Semicolon {
 		p.nextToken()
 	}
@@ -220,6 +286,63 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 	return stmt
 }
 
+func (p *Parser) parseThrowStatement() ast.Statement {
+	stmt := &ast.ThrowStatement{
+		Token: p.current,
+	}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(Lowest)
+	if stmt.Value == nil {
+		return nil
+	}
+
+	if p.peek.Type == token.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseImportStatement parses a statement that starts with `import`. `import "path/to/lib.monkey"` on its own is short
+// for `let lib = import "path/to/lib.monkey"`, any other statement is an expression statement.
+func (p *Parser) parseImportStatement() ast.Statement {
+	stmt := p.parseExpressionStatement()
+
+	exp, ok := stmt.Expression.(*ast.ImportExpression)
+	if !ok {
+		return stmt
+	}
+
+	path, ok := exp.Path.(*ast.StringLiteral)
+	if !ok {
+		return stmt
+	}
+
+	name, ok := moduleName(path.Value)
+	if !ok {
+		return stmt
+	}
+
+	return &ast.LetStatement{
+		Token: token.Token{Type: token.Let, Literal: "let", Pos: exp.Token.Pos, End: exp.Token.End},
+		Name: &ast.Identifier{
+			Token: token.Token{Type: token.Identifier, Literal: name, Pos: path.Pos(), End: path.End()},
+			Value: name,
+		},
+		Value: exp,
+	}
+}
+
+// moduleName returns the file name of `path` without its extension, if it is a valid identifier.
+func moduleName(path string) (string, bool) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	tok := lexer.NewLexer(name).NextToken()
+	return name, tok.Type == token.Identifier && tok.Literal == name
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{
 		Token: p.current,
@@ -283,6 +406,27 @@ func (p *Parser) currentPrecedence() int {
 	return Lowest
 }
 
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.current,
+		Target:   target,
+		Operator: p.current.Literal,
+	}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		p.invalidAssignmentTargetError(target)
+		return nil
+	}
+
+	// assignment is right-associative, so `a = b = 1` assigns 1 to `b` first
+	p.nextToken()
+	expression.Value = p.parseExpression(Assign - 1)
+
+	return expression
+}
+
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
 		Token:    p.current,
@@ -353,6 +497,71 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return exp
 }
 
+// parseImportExpression parses `import "path"`. The path is a single operand, so `import("lib.monkey").add` reads the
+// member of the module.
+func (p *Parser) parseImportExpression() ast.Expression {
+	exp := &ast.ImportExpression{
+		Token: p.current,
+	}
+
+	p.nextToken()
+
+	exp.Path = p.parseExpression(Index)
+	if exp.Path == nil {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseTryExpression() ast.Expression {
+	exp := &ast.TryExpression{
+		Token: p.current,
+	}
+
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	exp.Block = p.parseBlockStatement()
+
+	if p.peek.Type == token.Catch {
+		p.nextToken()
+
+		if !p.expectPeek(token.LeftParenthesis) || !p.expectPeek(token.Identifier) {
+			return nil
+		}
+
+		exp.CatchParameter = &ast.Identifier{
+			Token: p.current,
+			Value: p.current.Literal,
+		}
+
+		if !p.expectPeek(token.RightParenthesis) || !p.expectPeek(token.LeftBrace) {
+			return nil
+		}
+
+		exp.Catch = p.parseBlockStatement()
+	}
+
+	if p.peek.Type == token.Finally {
+		p.nextToken()
+
+		if !p.expectPeek(token.LeftBrace) {
+			return nil
+		}
+
+		exp.Finally = p.parseBlockStatement()
+	}
+
+	if exp.Catch == nil && exp.Finally == nil {
+		p.missingCatchOrFinallyError(exp.Token)
+		return nil
+	}
+
+	return exp
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{
 		Token: p.current,
@@ -368,6 +577,8 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 		p.nextToken()
 	}
+	block.RightBrace = p.current
+
 	return block
 }
 
@@ -380,49 +591,110 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	lit.Parameters, lit.Defaults, lit.Rest = p.parseFunctionParameters()
 
 	if !p.expectPeek(token.LeftBrace) {
 		return nil
 	}
 
-	lit.Body = p.parseBlockStatement()
+	lit.Body = p.parseFunctionBody()
 
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	lit := &ast.MacroLiteral{
+		Token: p.current,
+	}
 
-	if p.peek.Type == token.RightParenthesis {
-		p.nextToken()
-		return identifiers
+	if !p.expectPeek(token.LeftParenthesis) {
+		return nil
 	}
 
-	p.nextToken()
+	var defaults []ast.Expression
+	var rest *ast.Identifier
+	lit.Parameters, defaults, rest = p.parseFunctionParameters()
+	if defaults != nil || rest != nil {
+		p.macroParametersError(lit.Token)
+	}
 
-	ident := &ast.Identifier{
-		Token: p.current,
-		Value: p.current.Literal,
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
 	}
-	identifiers = append(identifiers, ident)
 
-	for p.peek.Type == token.Comma {
-		p.nextToken()
+	lit.Body = p.parseFunctionBody()
+
+	return lit
+}
+
+func (p *Parser) parseFunctionBody() *ast.BlockStatement {
+	// `break` and `continue` can't jump out of the function
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	body := p.parseBlockStatement()
+	p.loopDepth = loopDepth
+
+	return body
+}
+
+// parseFunctionParameters parses parameters like `(a, b = 2, ...rest)`. Once a parameter has a default value, the
+// following ones need one too, and the rest parameter must be the last one.
+func (p *Parser) parseFunctionParameters() (params []*ast.Identifier, defaults []ast.Expression, rest *ast.Identifier) {
+	params = []*ast.Identifier{}
+
+	if p.peek.Type == token.RightParenthesis {
 		p.nextToken()
+		return params, nil, nil
+	}
+
+	for {
+		if p.peek.Type == token.Ellipsis {
+			p.nextToken()
+			if !p.expectPeek(token.Identifier) {
+				return nil, nil, nil
+			}
+
+			rest = &ast.Identifier{
+				Token: p.current,
+				Value: p.current.Literal,
+			}
+			break
+		}
+
+		if !p.expectPeek(token.Identifier) {
+			return nil, nil, nil
+		}
 
 		ident := &ast.Identifier{
 			Token: p.current,
 			Value: p.current.Literal,
 		}
-		identifiers = append(identifiers, ident)
+		params = append(params, ident)
+
+		if p.peek.Type == token.Assign {
+			p.nextToken()
+			p.nextToken()
+
+			if defaults == nil {
+				defaults = make([]ast.Expression, len(params)-1)
+			}
+			defaults = append(defaults, p.parseExpression(Lowest))
+		} else if defaults != nil {
+			p.missingDefaultError(ident)
+			defaults = append(defaults, nil)
+		}
+
+		if p.peek.Type != token.Comma {
+			break
+		}
+		p.nextToken()
 	}
 
 	if !p.expectPeek(token.RightParenthesis) {
-		return nil
+		return nil, nil, nil
 	}
 
-	return identifiers
+	return params, defaults, rest
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -431,6 +703,7 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 		Function: function,
 	}
 	exp.Arguments = p.parseExpressionList(token.RightParenthesis)
+	exp.RightParenthesis = p.current
 	return exp
 }
 
@@ -441,12 +714,35 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{
+		Token: p.current,
+		Parts: []ast.Expression{p.parseStringLiteral()},
+	}
+
+	for p.current.Type != token.StringTail {
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(Lowest))
+
+		// the lexer turns the `}` closing the interpolation into the next part of the string
+		if p.peek.Type != token.StringMiddle && p.peek.Type != token.StringTail {
+			p.peekError(token.RightBrace)
+			return nil
+		}
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseStringLiteral())
+	}
+
+	return str
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	al := &ast.ArrayLiteral{
 		Token: p.current,
 	}
 
 	al.Elements = p.parseExpressionList(token.RightBracket)
+	al.RightBracket = p.current
 
 	return al
 }
@@ -475,6 +771,7 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	return expressions
 }
 
+// parseIndexExpression parses `left[index]`, or a slice `left[low:high]`.
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{
 		Token: p.current,
@@ -482,11 +779,200 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	}
 
 	p.nextToken()
+	if p.current.Type == token.Colon {
+		return p.parseSliceExpression(exp.Token, left, nil)
+	}
+
 	exp.Index = p.parseExpression(Lowest)
+	if p.peek.Type == token.Colon {
+		p.nextToken()
+		return p.parseSliceExpression(exp.Token, left, exp.Index)
+	}
 
 	if !p.expectPeek(token.RightBracket) {
 		return nil
 	}
+	exp.RightBracket = p.current
 
 	return exp
 }
+
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{
+		Token: p.current,
+		Left:  left,
+	}
+
+	if !p.expectPeek(token.Identifier) {
+		return nil
+	}
+
+	exp.Member = &ast.Identifier{
+		Token: p.current,
+		Value: p.current.Literal,
+	}
+
+	return exp
+}
+
+// parseSliceExpression parses the rest of a slice, starting at its `:`.
+func (p *Parser) parseSliceExpression(leftBracket token.Token, left ast.Expression, low ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{
+		Token: leftBracket,
+		Left:  left,
+		Low:   low,
+	}
+
+	if p.peek.Type != token.RightBracket {
+		p.nextToken()
+		exp.High = p.parseExpression(Lowest)
+	}
+
+	if !p.expectPeek(token.RightBracket) {
+		return nil
+	}
+	exp.RightBracket = p.current
+
+	return exp
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{
+		Token: p.current,
+	}
+	hash.Pairs = []ast.HashPair{}
+
+	for p.peek.Type != token.RightBrace {
+		p.nextToken()
+		key := p.parseExpression(Lowest)
+
+		if !p.expectPeek(token.Colon) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(Lowest)
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if p.peek.Type != token.RightBrace && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RightBrace) {
+		return nil
+	}
+	hash.RightBrace = p.current
+
+	return hash
+}
+
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{
+		Token: p.current,
+	}
+
+	if !p.expectPeek(token.LeftParenthesis) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt.Condition = p.parseExpression(Lowest)
+
+	if !p.expectPeek(token.RightParenthesis) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{
+		Token: p.current,
+	}
+
+	if !p.expectPeek(token.LeftParenthesis) {
+		return nil
+	}
+
+	if !p.expectPeek(token.Identifier) {
+		return nil
+	}
+
+	stmt.Variable = &ast.Identifier{
+		Token: p.current,
+		Value: p.current.Literal,
+	}
+
+	if !p.expectPeek(token.In) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt.Iterable = p.parseExpression(Lowest)
+
+	if !p.expectPeek(token.RightParenthesis) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+
+	if p.peek.Type == token.Semicolon {
+		p.nextToken()
+	}
+
+	return body
+}
+
+func (p *Parser) parseBreakStatement() ast.Statement {
+	stmt := &ast.BreakStatement{
+		Token: p.current,
+	}
+
+	if p.loopDepth == 0 {
+		p.outsideLoopError()
+	}
+
+	if p.peek.Type == token.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() ast.Statement {
+	stmt := &ast.ContinueStatement{
+		Token: p.current,
+	}
+
+	if p.loopDepth == 0 {
+		p.outsideLoopError()
+	}
+
+	if p.peek.Type == token.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt
+}
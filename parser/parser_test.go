@@ -131,6 +131,45 @@ func TestIntegerLiteral(t *testing.T) {
 
 // end region integer literal
 
+// region float literal
+
+func TestFloatLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1e-9;", 1e-9},
+		{"2.5E3;", 2500},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program has not enough statements. expected=`1` statement, actual=`%d` statement(s).", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not `*ast.ExpressionStatement`, but rather `%T`", program.Statements[0])
+		}
+
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("expression is not `*ast.FloatLiteral`, but rather `%T`", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value is not `%g`, but rather `%g`", tt.expected, literal.Value)
+		}
+	}
+}
+
+// end region float literal
+
 // region prefix expressions
 
 type PrefixTest struct {
@@ -250,6 +289,9 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{input: "false", expected: "false"},
 		{input: "3 > 5 == false", expected: "((3 > 5) == false)"},
 		{input: "3 < 5 == true", expected: "((3 < 5) == true)"},
+		{input: "len(a) > 0 && first(a) == 1", expected: "((len(a) > 0) && (first(a) == 1))"},
+		{input: "a || b && c || d", expected: "((a || (b && c)) || d)"},
+		{input: "x = a || !b", expected: "(x = (a || (!b)))"},
 		{input: "1 + (2 + 3) + 4", expected: "((1 + (2 + 3)) + 4)"},
 		{input: "(5 + 5) * 2", expected: "((5 + 5) * 2)"},
 		{input: "2 / (5 + 5)", expected: "(2 / (5 + 5))"},
@@ -259,6 +301,15 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{input: "add(a, b, 1, 2 * 3, 4 + 5)", expected: "add(a, b, 1, (2 * 3), (4 + 5))"},
 		{input: "a * [1, 2, 3, 4][b * c] * d", expected: "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
 		{input: "add(a * b[2], b[1], 2 * [1, 2][1])", expected: "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{input: "a + b % c * d", expected: "(a + ((b % c) * d))"},
+		{input: "x %= 2", expected: "(x %= 2)"},
+		{input: "x = y + 1", expected: "(x = (y + 1))"},
+		{input: "a = b = c == d", expected: "(a = (b = (c == d)))"},
+		{input: "x += 2 * 3", expected: "(x += (2 * 3))"},
+		{input: "arr[i + 1] -= f(x)", expected: "((arr[(i + 1)]) -= f(x))"},
+		{input: "h[k] *= 2; x /= 2", expected: "((h[k]) *= 2)\n(x /= 2)"},
+		{input: "a.b.c(1) + d.e[0]", expected: "(((a.b).c)(1) + ((d.e)[0]))"},
+		{input: "-lib.x * 2", expected: "((-(lib.x)) * 2)"},
 	}
 
 	for _, tt := range tests {
@@ -453,6 +504,47 @@ type FunctionParamTest struct {
 	expectedParams []string
 }
 
+func TestMacroLiteralParsing(t *testing.T) {
+	input := "macro(x, y) { x + y; }"
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. expected=`1` statement, actual=`%d` statement(s).", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not `*ast.ExpressionStatement`, but rather `%T`", program.Statements[0])
+	}
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("wrong type for stmt.Expression. exptected=`*ast.MacroLiteral`, actual=`%T`", stmt.Expression)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("wrong macro.Parameters length. expected=`2`, actual=`%d`", len(macro.Parameters))
+	}
+
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+
+	if len(macro.Body.Statements) != 1 {
+		t.Fatalf("wrong macro.Body.Statements length. expected=`1`, actual=`%d`", len(macro.Body.Statements))
+	}
+
+	bodyStmt, ok := macro.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("wrong type for macro.Body.Statements[0]. expected=`*ast.ExpressionStatement`, actual=`%T`", macro.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
 func TestFunctionParameterParsing(t *testing.T) {
 	tests := []FunctionParamTest{
 		{input: "fn() {};", expectedParams: []string{}},
@@ -486,6 +578,56 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestFunctionDefaultAndRestParameters(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedParams   []string
+		expectedDefaults []string // String() of each default value, empty for a parameter without one
+		expectedRest     string
+	}{
+		{input: "fn(a, b = 2) {}", expectedParams: []string{"a", "b"}, expectedDefaults: []string{"", "2"}},
+		{input: "fn(a = 1, b = a * 2) {}", expectedParams: []string{"a", "b"}, expectedDefaults: []string{"1", "(a * 2)"}},
+		{input: "fn(...rest) {}", expectedParams: []string{}, expectedRest: "rest"},
+		{input: "fn(a, b = [], ...rest) {}", expectedParams: []string{"a", "b"}, expectedDefaults: []string{"", "[]"}, expectedRest: "rest"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		function, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("wrong type for stmt.Expression. expected=`*ast.FunctionLiteral`, actual=`%T`", program.Statements[0])
+		}
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Fatalf("wrong function.Parameters length. expected=`%d`, actual=`%d`", len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+
+			actual := ""
+			if def := function.Default(i); def != nil {
+				actual = def.String()
+			}
+			if actual != tt.expectedDefaults[i] {
+				t.Errorf("wrong default of parameter %d for input `%s`. expected=`%s`, actual=`%s`", i, tt.input, tt.expectedDefaults[i], actual)
+			}
+		}
+
+		actualRest := ""
+		if function.Rest != nil {
+			actualRest = function.Rest.Value
+		}
+		if actualRest != tt.expectedRest {
+			t.Errorf("wrong rest parameter for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expectedRest, actualRest)
+		}
+	}
+}
+
 // end region function literal
 
 // region call expression
@@ -549,6 +691,39 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestInterpolatedString(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedParts int
+		expected      string
+	}{
+		{input: `"hello ${name}"`, expectedParts: 3, expected: `"hello ${name}"`},
+		{input: `"${a + 1}${b}!"`, expectedParts: 5, expected: `"${(a + 1)}${b}!"`},
+		{input: `"outer ${"inner ${x * 2}"}"`, expectedParts: 3, expected: `"outer ${"inner ${(x * 2)}"}"`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		str, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("wrong type for `stmt`. expected=`*ast.InterpolatedString`, actual=`%T`", stmt.Expression)
+		}
+
+		if len(str.Parts) != tt.expectedParts {
+			t.Errorf("wrong number of parts for input `%s`. expected=`%d`, actual=`%d`", tt.input, tt.expectedParts, len(str.Parts))
+		}
+
+		if str.String() != tt.expected {
+			t.Errorf("wrong string for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expected, str.String())
+		}
+	}
+}
+
 // end region string literal expression
 
 // region array literal expression
@@ -600,3 +775,355 @@ func TestParsingIndexExpressions(t *testing.T) {
 		return
 	}
 }
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "s[1:3]", expected: "(s[1:3])"},
+		{input: "s[:n - 1]", expected: "(s[:(n - 1)])"},
+		{input: "s[2:]", expected: "(s[2:])"},
+		{input: "s[:]", expected: "(s[:])"},
+		{input: "a[0][1:][:2]", expected: "(((a[0])[1:])[:2])"},
+		{input: `{"k": s[1:2]}`, expected: "{k: (s[1:2])}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+// region hash literal expression
+
+func TestParsingHashLiterals(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("wrong type for `stmt.Expression`. expected=`*ast.HashLiteral`, actual=`%T`", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 3 {
+		t.Fatalf("wrong length for `hash.Pairs`. expected=`3`, actual=`%d`", len(hash.Pairs))
+	}
+
+	expectedKeys := []string{"one", "two", "three"}
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("wrong type for key. expected=`*ast.StringLiteral`, actual=`%T`", pair.Key)
+			continue
+		}
+
+		if literal.Value != expectedKeys[i] {
+			t.Errorf("wrong key. expected=`%s`, actual=`%s`", expectedKeys[i], literal.Value)
+		}
+
+		testIntegerLiteral(t, pair.Value, int64(i+1))
+	}
+}
+
+func TestParsingEmptyHashLiteral(t *testing.T) {
+	input := "{}"
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("wrong type for `stmt.Expression`. expected=`*ast.HashLiteral`, actual=`%T`", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 0 {
+		t.Fatalf("wrong length for `hash.Pairs`. expected=`0`, actual=`%d`", len(hash.Pairs))
+	}
+}
+
+func TestParsingHashLiteralsWithExpressions(t *testing.T) {
+	input := `{"one": 0 + 1, 2: 10 - 8, true: 15 / 5}`
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("wrong type for `stmt.Expression`. expected=`*ast.HashLiteral`, actual=`%T`", stmt.Expression)
+	}
+
+	expected := `{one: (0 + 1), 2: (10 - 8), true: (15 / 5)}`
+	if hash.String() != expected {
+		t.Fatalf("wrong `hash.String()`. expected=`%s`, actual=`%s`", expected, hash.String())
+	}
+
+	testInfixExpression(t, hash.Pairs[0].Value, 0, "+", 1)
+	testInfixExpression(t, hash.Pairs[1].Value, 10, "-", 8)
+	testInfixExpression(t, hash.Pairs[2].Value, 15, "/", 5)
+}
+
+// end region hash literal expression
+
+// region error positions
+
+type ParserErrorTest struct {
+	input          string
+	expectedErrors []string
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := "while (x < y) { x; break; }"
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. expected=`1` statement, actual=`%d` statement(s).", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not `*ast.WhileStatement`, but rather `%T`", program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("wrong length for stmt.Body.Statements. expected=`2`, actual=`%d`", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.BreakStatement); !ok {
+		t.Fatalf("wrong type for stmt.Body.Statements[1]. expected=`*ast.BreakStatement`, actual=`%T`", stmt.Body.Statements[1])
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	input := "for (x in items) { continue; x }"
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. expected=`1` statement, actual=`%d` statement(s).", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not `*ast.ForStatement`, but rather `%T`", program.Statements[0])
+	}
+
+	if !testIdentifier(t, stmt.Variable, "x") {
+		return
+	}
+
+	if !testIdentifier(t, stmt.Iterable, "items") {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("wrong length for stmt.Body.Statements. expected=`2`, actual=`%d`", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[0].(*ast.ContinueStatement); !ok {
+		t.Fatalf("wrong type for stmt.Body.Statements[0]. expected=`*ast.ContinueStatement`, actual=`%T`", stmt.Body.Statements[0])
+	}
+
+	if stmt.String() != "for(x in items) continue;x" {
+		t.Errorf("wrong stmt.String(). actual=`%s`", stmt.String())
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	input := `throw error("oops");`
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. expected=`1` statement, actual=`%d` statement(s).", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not `*ast.ThrowStatement`, but rather `%T`", program.Statements[0])
+	}
+
+	if _, ok := stmt.Value.(*ast.CallExpression); !ok {
+		t.Fatalf("wrong type for stmt.Value. expected=`*ast.CallExpression`, actual=`%T`", stmt.Value)
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedCatch   string // name of the catch parameter, empty without `catch`
+		expectedFinally bool
+		expectedString  string
+	}{
+		{input: "try { x } catch (e) { y }", expectedCatch: "e", expectedString: "try x catch (e) y"},
+		{input: "try { x } finally { z }", expectedFinally: true, expectedString: "try x finally z"},
+		{input: "try { x } catch (err) { y } finally { z }", expectedCatch: "err", expectedFinally: true, expectedString: "try x catch (err) y finally z"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program has not enough statements. expected=`1` statement, actual=`%d` statement(s).", len(program.Statements))
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.TryExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not `*ast.TryExpression`, but rather `%T`", stmt.Expression)
+		}
+
+		if !testIdentifier(t, exp.Block.Statements[0].(*ast.ExpressionStatement).Expression, "x") {
+			return
+		}
+
+		if tt.expectedCatch == "" {
+			if exp.Catch != nil || exp.CatchParameter != nil {
+				t.Errorf("unexpected catch block for input `%s`", tt.input)
+			}
+		} else if exp.Catch == nil || !testIdentifier(t, exp.CatchParameter, tt.expectedCatch) {
+			t.Errorf("wrong catch block for input `%s`", tt.input)
+		}
+
+		if (exp.Finally != nil) != tt.expectedFinally {
+			t.Errorf("wrong finally block for input `%s`. expected=`%t`, actual=`%t`", tt.input, tt.expectedFinally, exp.Finally != nil)
+		}
+
+		if exp.String() != tt.expectedString {
+			t.Errorf("wrong exp.String(). expected=`%s`, actual=`%s`", tt.expectedString, exp.String())
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedString string
+	}{
+		{input: `import "lib/math.monkey"`, expectedString: "let math = import lib/math.monkey;"},
+		{input: `import "util"`, expectedString: "let util = import util;"},
+		{input: `let m = import("lib/math.monkey")`, expectedString: "let m = import lib/math.monkey;"},
+		{input: `import("lib/math.monkey").add(1, 2)`, expectedString: "(import lib/math.monkey.add)(1, 2)"},
+		{input: `import "my-lib.monkey"`, expectedString: "import my-lib.monkey"},
+		{input: `import path`, expectedString: "import path"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program has not enough statements. expected=`1` statement, actual=`%d` statement(s).", len(program.Statements))
+		}
+
+		if program.String() != tt.expectedString {
+			t.Errorf("wrong program.String() for input `%s`. expected=`%s`, actual=`%s`", tt.input, tt.expectedString, program.String())
+		}
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	tests := []ParserErrorTest{
+		{input: "let = 5;", expectedErrors: []string{"test.monkey:1:5: next token error. expected=`Identifier`, actual=`=`"}},
+		{input: "let x = 5;\nlet y 6;", expectedErrors: []string{"test.monkey:2:7: next token error. expected=`=`, actual=`Integer`"}},
+		{input: "if (x) { x }\n  + ;", expectedErrors: []string{"test.monkey:2:5: no prefix parse function for ; found"}},
+		{input: "1 = 2;", expectedErrors: []string{"test.monkey:1:1: cannot assign to 1"}},
+		{input: "let a = 1;\nf() += 2;", expectedErrors: []string{"test.monkey:2:1: cannot assign to f()"}},
+		{input: "while (x) { }\nbreak;", expectedErrors: []string{"test.monkey:2:1: `break` outside of a loop"}},
+		{input: "for (x in y) { fn() { continue; } }", expectedErrors: []string{"test.monkey:1:23: `continue` outside of a loop"}},
+		{input: "let s = \"abc;\nlet t = 1;", expectedErrors: []string{"test.monkey:1:9: unterminated string"}},
+		{input: `"a ${x y}"`, expectedErrors: []string{"test.monkey:1:8: next token error. expected=`}`, actual=`Identifier`"}},
+		{input: `puts("a\qb")`, expectedErrors: []string{`test.monkey:1:8: unknown escape sequence: \q`}},
+		{input: "fn(a = 1, b) { a }", expectedErrors: []string{"test.monkey:1:11: parameter `b` needs a default value, since a parameter before it has one"}},
+		{input: "fn(...rest, a) { a }", expectedErrors: []string{"test.monkey:1:11: next token error. expected=`)`, actual=`,`"}},
+		{input: "let m = macro(a, ...b) { a };", expectedErrors: []string{"test.monkey:1:9: parameters of a macro can't have default values or collect the rest of the arguments"}},
+		{input: "let x = 1;\ntry { x }", expectedErrors: []string{"test.monkey:2:1: `try` without `catch` or `finally`"}},
+		{input: "try { x } catch { y }", expectedErrors: []string{"test.monkey:1:17: next token error. expected=`(`, actual=`{`"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewFileLexer("test.monkey", tt.input)
+		p := NewParser(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) < len(tt.expectedErrors) {
+			t.Fatalf("not enough parser errors for input `%s`. expected=`%d`, actual=`%d`", tt.input, len(tt.expectedErrors), len(errors))
+		}
+
+		for i, expected := range tt.expectedErrors {
+			if errors[i] != expected {
+				t.Errorf("wrong parser error for input `%s`. expected=`%s`, actual=`%s`", tt.input, expected, errors[i])
+			}
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := "let add = fn(a, b) {\n  a + b\n};\nadd(1, [2][0]);"
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	tests := []struct {
+		node          ast.Node
+		expectedStart string
+		expectedEnd   string
+	}{
+		{node: program, expectedStart: "1:1", expectedEnd: "4:15"},
+		{node: program.Statements[0], expectedStart: "1:1", expectedEnd: "3:2"},
+		{node: program.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body.Statements[0], expectedStart: "2:3", expectedEnd: "2:8"},
+		{node: program.Statements[1], expectedStart: "4:1", expectedEnd: "4:15"},
+		{node: program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments[1], expectedStart: "4:8", expectedEnd: "4:14"},
+	}
+
+	for _, tt := range tests {
+		if tt.node.Pos().String() != tt.expectedStart {
+			t.Errorf("wrong start position for `%s`. expected=`%s`, actual=`%s`", tt.node.String(), tt.expectedStart, tt.node.Pos())
+		}
+
+		if tt.node.End().String() != tt.expectedEnd {
+			t.Errorf("wrong end position for `%s`. expected=`%s`, actual=`%s`", tt.node.String(), tt.expectedEnd, tt.node.End())
+		}
+	}
+}
+
+// end region error positions
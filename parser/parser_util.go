@@ -2,15 +2,41 @@ package parser
 
 import (
 	"fmt"
+	"monkey/ast"
 	"monkey/token"
 )
 
 func (p *Parser) peekError(expected token.TokenType) {
-	msg := fmt.Sprintf("next token error. expected=`%s`, actual=`%s`", expected, p.peek.Type)
+	msg := fmt.Sprintf("%s: next token error. expected=`%s`, actual=`%s`", p.peek.Pos, expected, p.peek.Type)
 	p.errors = append(p.errors, msg)
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
+	msg := fmt.Sprintf("%s: no prefix parse function for %s found", p.current.Pos, t)
+	p.errors = append(p.errors, msg)
+}
+
+func (p *Parser) invalidAssignmentTargetError(target ast.Expression) {
+	msg := fmt.Sprintf("%s: cannot assign to %s", target.Pos(), target)
+	p.errors = append(p.errors, msg)
+}
+
+func (p *Parser) outsideLoopError() {
+	msg := fmt.Sprintf("%s: `%s` outside of a loop", p.current.Pos, p.current.Literal)
+	p.errors = append(p.errors, msg)
+}
+
+func (p *Parser) missingCatchOrFinallyError(try token.Token) {
+	msg := fmt.Sprintf("%s: `try` without `catch` or `finally`", try.Pos)
+	p.errors = append(p.errors, msg)
+}
+
+func (p *Parser) missingDefaultError(param *ast.Identifier) {
+	msg := fmt.Sprintf("%s: parameter `%s` needs a default value, since a parameter before it has one", param.Pos(), param.Value)
+	p.errors = append(p.errors, msg)
+}
+
+func (p *Parser) macroParametersError(macro token.Token) {
+	msg := fmt.Sprintf("%s: parameters of a macro can't have default values or collect the rest of the arguments", macro.Pos)
 	p.errors = append(p.errors, msg)
 }
@@ -2,20 +2,30 @@ package repl
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"monkey/compiler"
 	"monkey/evaluator"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
 	"monkey/util"
+	"monkey/vm"
 	"os"
 	"os/user"
 )
 
 const Prompt = ">> "
 
-func Start() {
+type Options struct {
+	// Engine is either `util.EngineEval` or `util.EngineVM`. The evaluator is used when it is empty.
+	Engine string
+	// CheckedArithmetic makes integer overflow a runtime error instead of wrapping around.
+	CheckedArithmetic bool
+}
+
+func Start(options Options) {
 	for i := 0; i <= 50; i++ {
 		fmt.Println("")
 	}
@@ -28,12 +38,21 @@ func Start() {
 	fmt.Printf("Hello, `%s`! This is the Monkey Programming Language from \"Writing An Interpreter in Go\"\n", user.Username)
 	fmt.Println("Feel free to try!")
 	fmt.Println("NOTE: to look at all available options, use `--help` argument.")
-	start(os.Stdin, os.Stdout)
+	eval := evaluator.New()
+	eval.CheckedArithmetic = options.CheckedArithmetic
+
+	if options.Engine == util.EngineVM {
+		startVM(os.Stdin, os.Stdout, eval)
+		return
+	}
+	start(os.Stdin, os.Stdout, eval)
 }
 
-func start(in io.Reader, out io.Writer) {
+func start(in io.Reader, out io.Writer, eval *evaluator.Evaluator) {
+	eval.Out = out
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	macroEnv := object.NewEnvironment()
 
 	for {
 		fmt.Fprint(out, Prompt)
@@ -52,10 +71,85 @@ func start(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		evaluated := evaluator.Eval(program, env)
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, errObj := eval.ExpandMacros(program, macroEnv)
+		if errObj != nil {
+			io.WriteString(out, errObj.Inspect()+"\n")
+			continue
+		}
+
+		evaluated := eval.Eval(expanded, env)
+		if errObj, ok := evaluated.(*object.Error); ok {
+			io.WriteString(out, errObj.Traceback()+"\n")
+			continue
+		}
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
 	}
 }
+
+func startVM(in io.Reader, out io.Writer, eval *evaluator.Evaluator) {
+	eval.Out = out
+	scanner := bufio.NewScanner(in)
+
+	// the state is kept between lines, so later lines can use the globals of earlier ones
+	constants := []object.Object{}
+	globals := make([]object.Object, vm.GlobalsSize)
+	symbolTable := compiler.NewSymbolTable()
+	macroEnv := object.NewEnvironment()
+
+	for {
+		fmt.Fprint(out, Prompt)
+		scanned := scanner.Scan()
+		if !scanned {
+			return
+		}
+
+		line := scanner.Text()
+		l := lexer.NewLexer(line)
+		p := parser.NewParser(l)
+
+		program := p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			util.PrintParserErrors(out, p.Errors())
+			continue
+		}
+
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, errObj := eval.ExpandMacros(program, macroEnv)
+		if errObj != nil {
+			io.WriteString(out, errObj.Inspect()+"\n")
+			continue
+		}
+
+		comp := compiler.NewWithState(symbolTable, constants)
+		err := comp.Compile(expanded)
+		if err != nil {
+			fmt.Fprintf(out, "ERROR: compilation failed: %s\n", err)
+			continue
+		}
+
+		bytecode := comp.Bytecode()
+		constants = bytecode.Constants
+
+		machine := vm.NewWithGlobalsStore(bytecode, globals)
+		machine.Evaluator = eval
+		err = machine.Run()
+		var runtimeErr *vm.RuntimeError
+		if errors.As(err, &runtimeErr) {
+			io.WriteString(out, runtimeErr.Err.Traceback()+"\n")
+			continue
+		} else if err != nil {
+			fmt.Fprintf(out, "ERROR: %s\n", err)
+			continue
+		}
+
+		result := machine.LastResult()
+		if result != nil {
+			io.WriteString(out, result.Inspect())
+			io.WriteString(out, "\n")
+		}
+	}
+}
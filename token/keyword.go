@@ -1,13 +1,24 @@
 package token
 
 var keywords = map[string]TokenType{
-	"fn":     Function,
-	"let":    Let,
-	"if":     If,
-	"else":   Else,
-	"return": Return,
-	"true":   True,
-	"false":  False,
+	"fn":       Function,
+	"let":      Let,
+	"if":       If,
+	"else":     Else,
+	"return":   Return,
+	"true":     True,
+	"false":    False,
+	"while":    While,
+	"for":      For,
+	"in":       In,
+	"break":    Break,
+	"continue": Continue,
+	"macro":    Macro,
+	"throw":    Throw,
+	"try":      Try,
+	"catch":    Catch,
+	"finally":  Finally,
+	"import":   Import,
 }
 
 func LookupIdentifier(ident string) TokenType {
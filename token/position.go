@@ -0,0 +1,32 @@
+package token
+
+import "fmt"
+
+// Position is a location in the source code. Line and Column start at 1, while Offset is the byte offset starting at 0.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position as `file:line:col`, or `line:col` when there is no file name.
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	if s == "" {
+		s = "-"
+	}
+
+	return s
+}
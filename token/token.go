@@ -5,6 +5,8 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first character of the token
+	End     Position // position right after the last character of the token
 }
 
 const (
@@ -14,6 +16,7 @@ const (
 	// variable identifier and literal
 	Identifier = "Identifier"
 	Integer    = "Integer"
+	Float      = "Float"
 
 	// operators
 	Assign   = "="
@@ -22,6 +25,13 @@ const (
 	Bang     = "!"
 	Asterisk = "*"
 	Slash    = "/"
+	Percent  = "%"
+
+	PlusAssign     = "+="
+	MinusAssign    = "-="
+	AsteriskAssign = "*="
+	SlashAssign    = "/="
+	PercentAssign  = "%="
 
 	LessThan           = "<"
 	GreaterThan        = ">"
@@ -35,6 +45,9 @@ const (
 	// delimiters
 	Comma     = ","
 	Semicolon = ";"
+	Colon     = ":"
+	Ellipsis  = "..."
+	Dot       = "."
 
 	LeftParenthesis  = "("
 	RightParenthesis = ")"
@@ -49,9 +62,26 @@ const (
 	Return   = "Return"
 	True     = "True"
 	False    = "False"
+	While    = "While"
+	For      = "For"
+	In       = "In"
+	Break    = "Break"
+	Continue = "Continue"
+	Macro    = "Macro"
+	Throw    = "Throw"
+	Try      = "Try"
+	Catch    = "Catch"
+	Finally  = "Finally"
+	Import   = "Import"
 
 	String = "String"
 
+	// parts of a string with `${...}` interpolations, e.g. `"a ${x} b ${y} c"` is
+	// StringHead("a "), x, StringMiddle(" b "), y, StringTail(" c")
+	StringHead   = "StringHead"
+	StringMiddle = "StringMiddle"
+	StringTail   = "StringTail"
+
 	// array
 	LeftBracket  = "["
 	RightBracket = "]"
@@ -0,0 +1,7 @@
+package util
+
+// Engines that can run a program.
+const (
+	EngineEval = "eval" // the tree-walking evaluator
+	EngineVM   = "vm"   // the bytecode compiler and virtual machine
+)
@@ -0,0 +1,32 @@
+package vm
+
+import (
+	"monkey/code"
+	"monkey/object"
+	"monkey/token"
+)
+
+type Frame struct {
+	cl          *object.Closure
+	ip          int // instruction pointer
+	basePointer int // stack pointer before the call, locals are stored right above it
+
+	byBuiltIn bool // whether a built-in function made the call, so it has no position in the source
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{
+		cl:          cl,
+		ip:          -1,
+		basePointer: basePointer,
+	}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
+
+// pos returns the position of the instruction the frame is executing, e.g. the call of the frame above it.
+func (f *Frame) pos() token.Position {
+	return f.cl.Fn.PosAt(f.ip)
+}
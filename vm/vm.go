@@ -0,0 +1,816 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"monkey/code"
+	"monkey/compiler"
+	"monkey/evaluator"
+	"monkey/object"
+	"monkey/token"
+)
+
+const (
+	StackSize    = 2048 // slots the stack starts with, it grows up to MaxStackSize
+	MaxStackSize = 1 << 20
+	GlobalsSize  = 65536
+	MaxFrames    = 1 << 17 // calls deeper than this are a stack overflow, which is far deeper than any sane recursion
+)
+
+// operators maps the opcodes of the infix operators back to the operator, whose semantics are shared with the evaluator.
+var operators = map[code.Opcode]string{
+	code.OpAdd:                "+",
+	code.OpSub:                "-",
+	code.OpMul:                "*",
+	code.OpDiv:                "/",
+	code.OpMod:                "%",
+	code.OpEqual:              "==",
+	code.OpNotEqual:           "!=",
+	code.OpLessThan:           "<",
+	code.OpLessThanOrEqual:    "<=",
+	code.OpGreaterThan:        ">",
+	code.OpGreaterThanOrEqual: ">=",
+}
+
+type VM struct {
+	// Evaluator supplies the semantics of the operators, e.g. whether integer arithmetic is checked
+	Evaluator *evaluator.Evaluator
+
+	constants   []object.Object
+	globals     []object.Object
+	globalNames []string
+
+	stack []object.Object
+	sp    int // always points to the next free slot, so the top of the stack is stack[sp-1]
+
+	frames      []*Frame
+	framesIndex int
+
+	handlers []handler // error handlers of the running try blocks, the innermost one last
+
+	// lastResult is the value of the last expression statement, or the value returned from the top level
+	lastResult object.Object
+}
+
+// RuntimeError is an error that stops the VM. Unlike the errors of the compiler, it has a position and a stack.
+type RuntimeError struct {
+	Err *object.Error
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Err.Message
+}
+
+// handler is where the VM continues when an error happens in a try block. The frames and the stack are restored to
+// their state at the start of the block.
+type handler struct {
+	ip          int
+	framesIndex int
+	sp          int
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithGlobalsStore(bytecode, make([]object.Object, GlobalsSize))
+}
+
+// NewWithGlobalsStore creates a VM which keeps the globals of a previous run, e.g. the previous line in the REPL.
+func NewWithGlobalsStore(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions, Positions: bytecode.Positions}
+	mainClosure := &object.Closure{Fn: mainFn}
+	mainFrame := NewFrame(mainClosure, 0)
+
+	frames := make([]*Frame, 1, 64)
+	frames[0] = mainFrame
+
+	return &VM{
+		Evaluator: evaluator.New(),
+
+		constants:   bytecode.Constants,
+		globals:     globals,
+		globalNames: bytecode.GlobalNames,
+
+		stack: make([]object.Object, StackSize),
+		sp:    0,
+
+		frames:      frames,
+		framesIndex: 1,
+	}
+}
+
+// LastResult returns the value of the program, the same value `evaluator.Eval` returns for it.
+func (vm *VM) LastResult() object.Object {
+	return vm.lastResult
+}
+
+func (vm *VM) Run() error {
+	return vm.run(0)
+}
+
+// run executes instructions until the program ends, or until a return brings the number of frames down to `returnTo`.
+// An error is handled by the innermost try block that started during this run, otherwise it's a `*RuntimeError`.
+func (vm *VM) run(returnTo int) error {
+	for {
+		err := vm.execute(returnTo)
+		if err == nil {
+			return nil
+		}
+
+		errObj := vm.errorObject(err)
+		if !vm.handleError(errObj, returnTo) {
+			vm.unwind(errObj, returnTo)
+			return &RuntimeError{Err: errObj}
+		}
+	}
+}
+
+// errorObject returns the error that `err` raises, which happened at the instruction the current frame executes
+// unless it knows its position already, e.g. because it happened in an inner call.
+func (vm *VM) errorObject(err error) *object.Error {
+	var errObj *object.Error
+
+	var runtimeErr *RuntimeError
+	if errors.As(err, &runtimeErr) {
+		errObj = runtimeErr.Err
+	} else {
+		errObj = &object.Error{Message: err.Error()}
+	}
+
+	if !errObj.Pos.IsValid() {
+		errObj.Pos = vm.currentFrame().pos()
+	}
+
+	return errObj
+}
+
+// handleError continues at the handler of the innermost try block, with `errObj` on the stack. It reports false when
+// there is no such try block above the frame `returnTo`.
+func (vm *VM) handleError(errObj *object.Error, returnTo int) bool {
+	if len(vm.handlers) == 0 {
+		return false
+	}
+
+	h := vm.handlers[len(vm.handlers)-1]
+	if h.framesIndex <= returnTo {
+		return false
+	}
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	vm.unwind(errObj, h.framesIndex)
+	vm.sp = h.sp
+	vm.currentFrame().ip = h.ip - 1
+
+	return vm.push(errObj) == nil
+}
+
+// unwind drops the frames above the first `framesIndex` ones, and adds their calls to the stack of `errObj`, innermost
+// first like the evaluator does. The main frame is not a call, so it's never added.
+func (vm *VM) unwind(errObj *object.Error, framesIndex int) {
+	for i := vm.framesIndex - 1; i >= framesIndex && i > 0; i-- {
+		frame := vm.frames[i]
+
+		var pos token.Position
+		if !frame.byBuiltIn {
+			pos = vm.frames[i-1].pos()
+		}
+		errObj.Stack = append(errObj.Stack, object.StackFrame{Function: frame.cl.Fn.DisplayName(), Pos: pos})
+	}
+
+	if framesIndex < vm.framesIndex {
+		vm.framesIndex = framesIndex
+	}
+}
+
+func (vm *VM) execute(returnTo int) error {
+	var ip int
+	var ins code.Instructions
+	var op code.Opcode
+
+	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		vm.currentFrame().ip++
+
+		ip = vm.currentFrame().ip
+		ins = vm.currentFrame().Instructions()
+		op = code.Opcode(ins[ip])
+
+		switch op {
+		case code.OpConstant:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			err := vm.push(vm.constants[constIndex])
+			if err != nil {
+				return err
+			}
+		case code.OpPop:
+			result := vm.pop()
+			if vm.framesIndex == 1 {
+				vm.lastResult = result
+			}
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod,
+			code.OpEqual, code.OpNotEqual,
+			code.OpLessThan, code.OpLessThanOrEqual, code.OpGreaterThan, code.OpGreaterThanOrEqual:
+			right := vm.pop()
+			left := vm.pop()
+
+			err := vm.pushResult(vm.Evaluator.InfixOperator(operators[op], left, right))
+			if err != nil {
+				return err
+			}
+		case code.OpTrue:
+			err := vm.push(evaluator.True)
+			if err != nil {
+				return err
+			}
+		case code.OpFalse:
+			err := vm.push(evaluator.False)
+			if err != nil {
+				return err
+			}
+		case code.OpNull:
+			err := vm.push(evaluator.Null)
+			if err != nil {
+				return err
+			}
+		case code.OpBang:
+			err := vm.pushResult(vm.Evaluator.PrefixOperator("!", vm.pop()))
+			if err != nil {
+				return err
+			}
+		case code.OpMinus:
+			err := vm.pushResult(vm.Evaluator.PrefixOperator("-", vm.pop()))
+			if err != nil {
+				return err
+			}
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip = pos - 1
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			condition := vm.pop()
+			if !evaluator.IsTruthy(condition) {
+				vm.currentFrame().ip = pos - 1
+			}
+		case code.OpSetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			vm.globals[globalIndex] = vm.pop()
+		case code.OpGetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			global := vm.globals[globalIndex]
+			if global == nil {
+				return fmt.Errorf("identifier not found: %s", vm.globalName(int(globalIndex)))
+			}
+
+			err := vm.push(global)
+			if err != nil {
+				return err
+			}
+		case code.OpAssignGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			if vm.globals[globalIndex] == nil {
+				return fmt.Errorf("cannot assign to undeclared identifier: %s", vm.globalName(int(globalIndex)))
+			}
+
+			vm.globals[globalIndex] = vm.pop()
+		case code.OpSetLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			frame := vm.currentFrame()
+			slot := &vm.stack[frame.basePointer+int(localIndex)]
+			if cell, ok := (*slot).(*object.Cell); ok {
+				cell.Value = vm.pop()
+			} else {
+				*slot = vm.pop()
+			}
+		case code.OpGetLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			frame := vm.currentFrame()
+			local := unwrapCell(vm.stack[frame.basePointer+int(localIndex)])
+			if local == nil {
+				return fmt.Errorf("identifier not found: %s", variableName(frame.cl.Fn.LocalNames, int(localIndex), "local"))
+			}
+
+			err := vm.push(local)
+			if err != nil {
+				return err
+			}
+		case code.OpCaptureLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			frame := vm.currentFrame()
+			slot := &vm.stack[frame.basePointer+int(localIndex)]
+			if _, ok := (*slot).(*object.Cell); !ok {
+				*slot = &object.Cell{Value: *slot}
+			}
+
+			err := vm.push(*slot)
+			if err != nil {
+				return err
+			}
+		case code.OpGetFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			currentClosure := vm.currentFrame().cl
+			free := unwrapCell(currentClosure.Free[freeIndex])
+			if free == nil {
+				return fmt.Errorf("identifier not found: %s", variableName(currentClosure.Fn.FreeNames, int(freeIndex), "free"))
+			}
+
+			err := vm.push(free)
+			if err != nil {
+				return err
+			}
+		case code.OpSetFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			currentClosure := vm.currentFrame().cl
+			currentClosure.Free[freeIndex].(*object.Cell).Value = vm.pop()
+		case code.OpCaptureFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			currentClosure := vm.currentFrame().cl
+			err := vm.push(currentClosure.Free[freeIndex])
+			if err != nil {
+				return err
+			}
+		case code.OpGetGlobalOr:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			end := code.ReadUint16(ins[ip+3:])
+			vm.currentFrame().ip += 4
+
+			err := vm.pushBound(vm.globals[globalIndex], int(end))
+			if err != nil {
+				return err
+			}
+		case code.OpGetLocalOr:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			end := code.ReadUint16(ins[ip+2:])
+			vm.currentFrame().ip += 3
+
+			frame := vm.currentFrame()
+			err := vm.pushBound(unwrapCell(vm.stack[frame.basePointer+int(localIndex)]), int(end))
+			if err != nil {
+				return err
+			}
+		case code.OpGetFreeOr:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			end := code.ReadUint16(ins[ip+2:])
+			vm.currentFrame().ip += 3
+
+			currentClosure := vm.currentFrame().cl
+			err := vm.pushBound(unwrapCell(currentClosure.Free[freeIndex]), int(end))
+			if err != nil {
+				return err
+			}
+		case code.OpCurrentClosure:
+			currentClosure := vm.currentFrame().cl
+			err := vm.push(currentClosure)
+			if err != nil {
+				return err
+			}
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			elements := make([]object.Object, numElements)
+			copy(elements, vm.stack[vm.sp-numElements:vm.sp])
+			vm.sp = vm.sp - numElements
+
+			err := vm.push(&object.Array{Elements: elements})
+			if err != nil {
+				return err
+			}
+		case code.OpInterpolate:
+			numParts := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			str := evaluator.Interpolate(vm.stack[vm.sp-numParts : vm.sp])
+			vm.sp = vm.sp - numParts
+
+			err := vm.push(str)
+			if err != nil {
+				return err
+			}
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			hash, err := vm.buildHash(vm.sp-numElements, vm.sp)
+			if err != nil {
+				return err
+			}
+			vm.sp = vm.sp - numElements
+
+			err = vm.push(hash)
+			if err != nil {
+				return err
+			}
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+
+			err := vm.pushResult(evaluator.Index(left, index))
+			if err != nil {
+				return err
+			}
+		case code.OpSlice:
+			high := vm.pop()
+			low := vm.pop()
+			left := vm.pop()
+
+			err := vm.pushResult(evaluator.Slice(left, low, high))
+			if err != nil {
+				return err
+			}
+		case code.OpSetIndex:
+			value := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			err := vm.pushResult(vm.Evaluator.IndexAssignment("", left, index, value))
+			if err != nil {
+				return err
+			}
+		case code.OpUpdateIndex:
+			operator := operators[code.Opcode(ins[ip+1])]
+			vm.currentFrame().ip += 1
+
+			value := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			err := vm.pushResult(vm.Evaluator.IndexAssignment(operator, left, index, value))
+			if err != nil {
+				return err
+			}
+		case code.OpIterate:
+			err := vm.pushResult(evaluator.Iterate(vm.pop()))
+			if err != nil {
+				return err
+			}
+		case code.OpCall:
+			numArgs := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			err := vm.executeCall(int(numArgs))
+			if err != nil {
+				return err
+			}
+		case code.OpReturnValue:
+			returnValue := vm.pop()
+
+			if vm.framesIndex == 1 {
+				// `return` at the top level ends the program
+				vm.lastResult = returnValue
+				return nil
+			}
+
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err := vm.push(returnValue)
+			if err != nil {
+				return err
+			}
+			if vm.framesIndex == returnTo {
+				return nil
+			}
+		case code.OpReturn:
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err := vm.push(evaluator.Null)
+			if err != nil {
+				return err
+			}
+			if vm.framesIndex == returnTo {
+				return nil
+			}
+		case code.OpClosure:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			numFree := code.ReadUint8(ins[ip+3:])
+			vm.currentFrame().ip += 3
+
+			err := vm.pushClosure(int(constIndex), int(numFree))
+			if err != nil {
+				return err
+			}
+		case code.OpTry:
+			handlerPos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			vm.handlers = append(vm.handlers, handler{ip: handlerPos, framesIndex: vm.framesIndex, sp: vm.sp})
+		case code.OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case code.OpThrow:
+			value := vm.pop()
+			if errObj, ok := value.(*object.Error); ok {
+				// the error of a try block goes on after its `finally` block, with the calls it unwound so far
+				return &RuntimeError{Err: errObj}
+			}
+
+			return &RuntimeError{Err: evaluator.Throw(value)}
+		case code.OpCatch:
+			err := vm.push(evaluator.ErrorValue(vm.pop().(*object.Error)))
+			if err != nil {
+				return err
+			}
+		case code.OpImport:
+			fromIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			from := vm.constants[fromIndex].(*object.String)
+			err := vm.pushResult(vm.Evaluator.Import(vm.pop(), from.Value))
+			if err != nil {
+				return err
+			}
+		default:
+			def, err := code.Lookup(byte(op))
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("opcode %s is not supported", def.Name)
+		}
+	}
+
+	return nil
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) error {
+	if vm.framesIndex >= MaxFrames {
+		return errors.New("stack overflow")
+	}
+
+	if vm.framesIndex == len(vm.frames) {
+		vm.frames = append(vm.frames, f)
+	} else {
+		vm.frames[vm.framesIndex] = f
+	}
+	vm.framesIndex++
+	return nil
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+func (vm *VM) push(o object.Object) error {
+	err := vm.reserve(1)
+	if err != nil {
+		return err
+	}
+
+	vm.stack[vm.sp] = o
+	vm.sp++
+
+	return nil
+}
+
+// reserve grows the stack, so it has room for `n` more values.
+func (vm *VM) reserve(n int) error {
+	if vm.sp+n <= len(vm.stack) {
+		return nil
+	}
+	if vm.sp+n > MaxStackSize {
+		return errors.New("stack overflow")
+	}
+
+	size := len(vm.stack) * 2
+	for size < vm.sp+n {
+		size *= 2
+	}
+	if size > MaxStackSize {
+		size = MaxStackSize
+	}
+
+	stack := make([]object.Object, size)
+	copy(stack, vm.stack[:vm.sp])
+	vm.stack = stack
+
+	return nil
+}
+
+// pushBound pushes the value of a variable and continues at `end`, which skips the code that loads the binding the
+// variable falls back to. A variable that is not bound yet pushes nothing, so that code runs instead.
+func (vm *VM) pushBound(value object.Object, end int) error {
+	if value == nil {
+		return nil
+	}
+
+	vm.currentFrame().ip = end - 1
+	return vm.push(value)
+}
+
+// pushResult pushes the result of an operation, or stops the VM if the operation failed.
+func (vm *VM) pushResult(o object.Object) error {
+	if errObj, ok := o.(*object.Error); ok {
+		return &RuntimeError{Err: errObj}
+	}
+
+	return vm.push(o)
+}
+
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
+
+// unwrapCell returns the value of a variable, which is stored in a cell once a closure captured it.
+func unwrapCell(o object.Object) object.Object {
+	if cell, ok := o.(*object.Cell); ok {
+		return cell.Value
+	}
+
+	return o
+}
+
+func (vm *VM) globalName(index int) string {
+	return variableName(vm.globalNames, index, "global")
+}
+
+// variableName returns the name of the variable at `index` of `names`, or a placeholder when its name is unknown.
+func variableName(names []string, index int, kind string) string {
+	if index < len(names) && names[index] != "" {
+		return names[index]
+	}
+
+	return fmt.Sprintf("<%s %d>", kind, index)
+}
+
+func (vm *VM) buildHash(startIndex, endIndex int) (object.Object, error) {
+	hash := object.NewHash()
+
+	for i := startIndex; i < endIndex; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i+1]
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return hash, nil
+}
+
+func (vm *VM) executeCall(numArgs int) error {
+	callee := vm.stack[vm.sp-1-numArgs]
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, numArgs)
+	case *object.BuiltIn:
+		return vm.callBuiltIn(callee, numArgs)
+	case *object.Function:
+		return vm.callFunction(callee, numArgs)
+	default:
+		return fmt.Errorf("not a function: %s", callee.Type())
+	}
+}
+
+func (vm *VM) callClosure(cl *object.Closure, numArgs int) error {
+	fn := cl.Fn
+	if errObj := evaluator.ArityError(fn.DisplayName(), fn.NumRequired(), fn.NumParameters, fn.Rest, numArgs); errObj != nil {
+		return errors.New(errObj.Message)
+	}
+
+	frame := NewFrame(cl, vm.sp-numArgs)
+	err := vm.reserve(frame.basePointer + fn.NumLocals - vm.sp)
+	if err != nil {
+		return err
+	}
+
+	err = vm.pushFrame(frame)
+	if err != nil {
+		return err
+	}
+
+	var rest *object.Array
+	if fn.Rest {
+		rest = &object.Array{Elements: []object.Object{}}
+		if numArgs > fn.NumParameters {
+			rest.Elements = append(rest.Elements, vm.stack[frame.basePointer+fn.NumParameters:vm.sp]...)
+			vm.sp = frame.basePointer + fn.NumParameters
+			numArgs = fn.NumParameters
+		}
+	}
+
+	// the arguments are already in place as the first locals, the rest must not leak values of earlier calls
+	for i := vm.sp; i < frame.basePointer+fn.NumLocals; i++ {
+		vm.stack[i] = nil
+	}
+	vm.sp = frame.basePointer + fn.NumLocals
+
+	if rest != nil {
+		vm.stack[frame.basePointer+fn.NumParameters] = rest
+	}
+	if fn.Entries != nil {
+		frame.ip = fn.Entries[numArgs-fn.NumRequired()] - 1
+	}
+
+	return nil
+}
+
+// ApplyFunction calls `fn` on behalf of a built-in function, e.g. the function passed to `map`. A closure runs on top
+// of the current stack, until it returns.
+func (vm *VM) ApplyFunction(fn object.Object, args []object.Object) object.Object {
+	switch fn := fn.(type) {
+	case *object.Closure:
+		returnTo := vm.framesIndex
+		sp := vm.sp
+
+		err := vm.push(fn)
+		for i := 0; i < len(args) && err == nil; i++ {
+			err = vm.push(args[i])
+		}
+		if err == nil {
+			err = vm.callClosure(fn, len(args))
+		}
+		if err == nil {
+			vm.currentFrame().byBuiltIn = true
+			err = vm.run(returnTo)
+		}
+		if err != nil {
+			// the frames of the failed call are dropped by `run`, so the error can be handled further up
+			vm.framesIndex = returnTo
+			vm.sp = sp
+			return vm.errorObject(err)
+		}
+
+		return vm.pop()
+	case *object.BuiltIn:
+		return fn.Fn(vm, args...)
+	case *object.Function:
+		vm.Evaluator.Host = vm
+		return vm.Evaluator.ApplyFunction(fn, args)
+	default:
+		return &object.Error{Message: fmt.Sprintf("not a function: %s", fn.Type())}
+	}
+}
+
+// Output returns the writer of the built-in functions, which belongs to the evaluator of the VM.
+func (vm *VM) Output() io.Writer {
+	return vm.Evaluator.Output()
+}
+
+func (vm *VM) callBuiltIn(builtIn *object.BuiltIn, numArgs int) error {
+	args := vm.stack[vm.sp-numArgs : vm.sp]
+
+	result := builtIn.Fn(vm, args...)
+	vm.sp = vm.sp - numArgs - 1
+
+	if result == nil {
+		result = evaluator.Null
+	}
+
+	return vm.pushResult(result)
+}
+
+// callFunction calls a function of an imported module, which the evaluator runs, since modules are evaluated by it.
+func (vm *VM) callFunction(fn *object.Function, numArgs int) error {
+	args := make([]object.Object, numArgs)
+	copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+	vm.sp = vm.sp - numArgs - 1
+
+	vm.Evaluator.Host = vm
+	return vm.pushResult(vm.Evaluator.ApplyFunctionAt(fn, args, vm.currentFrame().pos()))
+}
+
+func (vm *VM) pushClosure(constIndex int, numFree int) error {
+	constant := vm.constants[constIndex]
+	function, ok := constant.(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %+v", constant)
+	}
+
+	free := make([]object.Object, numFree)
+	for i := 0; i < numFree; i++ {
+		free[i] = vm.stack[vm.sp-numFree+i]
+	}
+	vm.sp = vm.sp - numFree
+
+	closure := &object.Closure{Fn: function, Free: free}
+	return vm.push(closure)
+}
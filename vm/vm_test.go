@@ -0,0 +1,296 @@
+package vm
+
+import (
+	"monkey/compiler"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParityWithEvaluator runs the programs of the evaluator tests on both engines, which must agree on every result.
+func TestParityWithEvaluator(t *testing.T) {
+	modules := t.TempDir()
+	t.Setenv("MONKEYPATH", modules)
+	for name, content := range map[string]string{
+		"math.monkey":    `let add = fn(a, b) { a + b }; let twice = fn(f, x) { f(f(x)) }; let pi = 3; let _secret = 1;`,
+		"cycle.monkey":   `import "cycle";`,
+		"failing.monkey": `let x = 1 / 0;`,
+	} {
+		if err := os.WriteFile(filepath.Join(modules, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("cannot write module: %s", err)
+		}
+	}
+
+	inputs := []string{
+		// integers
+		"5", "-10", "1 + 2 + 3", "5 + 5 + 5 + 5 -10", "-50 + 100 + -50", "5 / 2", "3 * (3 * 3) - 6",
+		"7 % 3", "-7 % 3", "1 / 0", "5 % 0", "let x = 7; x %= 4; x", "9223372036854775807 + 1",
+
+		// floats
+		"3.14", "-2.5", "1e-9", "1 + 0.5", "7 / 2.0", "7.5 % 2", "1.0 / 0", "1 == 1.0", "2 >= 2.5",
+		"let x = 1; x *= 1.5; x", "int(3.99)", `float("1e3")`, "floor(-2.2)", "ceil(2.2)", "round(2.5)", "int(1e19)",
+
+		// booleans
+		"true && false", "false || true", "false && crash()", "true || crash()", "true && crash()", `1 && "a"`, "0 || false",
+		"let a = []; len(a) > 0 && first(a) == 1", "let calls = 0; let f = fn() { calls += 1; true }; false && f(); true || f(); calls",
+		"let calls = 0; let f = fn() { calls += 1; true }; true && f(); false || f(); calls", "let f = fn(x) { x > 1 && x < 5 || x == 10 }; [f(3), f(7), f(10)]", "1 < 2", "2 <= 2", "5 <= 2", "1 > 2", "3 >= 2", "1 == 1", "1 != 2",
+		"(1 < 2) == true", "(5 <= 2) == false", "(1 == 1) || false",
+		"!true", "!false", "!5", "!!true", "!!5",
+
+		// conditionals
+		"if (true) { 10 }", "if (false) { 10 }", "if (1) { 10 }", "if (1 > 2) { 10 } else { 20 }",
+
+		// return
+		"return 10; 9;", "9; return 2 * 5; 9;", "if (10 > 1) { if (10 > 1) { return 10; } return 1; }",
+
+		// errors
+		"5 + true", "5 + true; 5;", "-true", "true + false;", "5; true + false; 5;",
+		"if (10 > 1) { if (10 > 1) { return true + false; } return 1; }",
+		"foobar", `"Hello" - "world"`, `{"foo": 5}[fn(x) { x }]`, `{[1]: 5}`, "[1][true]",
+		"let f = fn() { if (false) { let x = 2; } len(x) }; f();", "let f = fn() { for (x in []) {} puts(x) }; f();",
+		"let f = fn() { if (false) { let x = 1; } fn() { len(x) } }; f()();",
+		"let x = 1; let f = fn() { let g = fn() { x }; let x = 2; g() }; f()",
+		"let f = fn() { let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(10) }; f()",
+		"let x = 1; let f = fn() { if (false) { let x = 2; } x }; f()",
+		"let x = 1; let f = fn() { if (false) { let x = 2; } fn() { x } }; f()()",
+		"let f = fn(x) { fn() { if (false) { let x = 2; } x } }; f(3)()", "let f = fn() { let n = len([1]); let len = 5; n + len }; f()",
+		"len([1]); let len = fn(x) { 0 }; len([1])", "let x = 1; let f = fn() { let x = x + 1; x }; [f(), x]",
+
+		// let statements
+		"let a = 5; a;", "let a = 5; let b = a; let c = a + b + 5; c;", "let a = 1; let a = a + 1; a",
+
+		// functions
+		"let identity = fn(x) { x; }; identity(5);", "let double = fn(x) { return x * 2; }; double(5);",
+		"let add = fn(x, y) { x + y; }; add(5, add(1, 2));", "fn(x) { x; }(5);",
+		"let newAdder = fn(x) { fn(y) { x + y } }; let addTwo = newAdder(2); addTwo(2);",
+		"let fib = fn(x) { if (x < 2) { return x; } fib(x - 1) + fib(x - 2) }; fib(15);",
+		"let wrapper = fn() { let countDown = fn(x) { if (x == 0) { return 0; } countDown(x - 1) }; countDown(5) }; wrapper();",
+		"let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } }; let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } }; isEven(10);",
+		"let noop = fn() { }; noop();", "1(2)",
+		"let depth = fn(n) { if (n == 0) { 0 } else { 1 + depth(n - 1) } }; depth(5000)",
+
+		// strings
+		`"Hello world!"`, `"Hello" + " " + "world";`,
+		`"a" == "a"`, `"abc" < "abd"`, `"b" >= "abc"`, `"ab" * 3`, `2 * "-"`, `"a" * -1`, `"hello"[1]`, `"hello"[1:3]`, `"hello"[:2]`,
+		`"hello"[-3:]`, `"hello"[:]`, `"abc"["a":]`, `5[1:2]`,
+		`split("a,b", ",")`, `join(["a", "b"], "-")`, `upper("abc")`, `index_of("日本語", "語")`, `replace("a", "b", 3)`,
+		`let name = "Ada"; "hi ${name}, ${1 + 1.5} ${[1, "a"]}"`, `"${"nested ${1 + 1}"}!"`, `"a ${missing} b"`, `"${1 / 0}"`,
+		`"say \"hi\"\n\u{263A}"`, "`raw\n${x}` + \"\\t\"",
+		`len("héllo")`, `first("ünicode")`, `last("smile 😀")`, `"日本語"[2]`, `"日本語"[3]`, `let s = ""; for (c in "añb") { s += c }; s`,
+
+		// built-in functions
+		`len("")`, `len("hello world")`, `len(1)`, `len("one", "two")`, `first("")`, `first("four")`, `last("four")`,
+		"let myArray = []; first(myArray);", "let myArray = [10, 15, 17]; last(myArray);",
+		"let a = [1,5,9]; let b = push(a, 8); b[3];", "let a = [1,5,9]; let b = push(a, 8); len(a);",
+		`keys({"a": 1, "b": 2})`, `values({"a": 1})`, `has({"a": 1}, "b")`, `delete({"a": 1, "b": 2}, "a")`,
+
+		// arrays and hashes
+		"[1, 2 * 2, 3 + 3]", "[1,2,3][1+1]", "[1,2,3][3]", "[1,2,3][-1]",
+		"[1,2,3][-3]", "[1,2,3][-4]", "let a = [1, 2]; a[-1] += 5; a", "let a = [1]; a[-2] = 5",
+		"[1, 2, 3, 4][1:3]", "[1, 2, 3, 4][-2:]", "[1, 2, 3][:]", "[1, 2, 3][1:true]",
+
+		// higher-order built-in functions, which call back into the vm
+		"rest([1, 2, 3])", "map([1, 2, 3], fn(x) { x * 2 })", "let n = 10; map([1, 2], fn(x) { x + n })", `map(["a"], upper)`,
+		"map([[1, 2], [3]], fn(xs) { map(xs, fn(x) { x * 10 }) })", "filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })",
+		"reduce([1, 2, 3, 4], fn(acc, x) { acc + x })", "reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)",
+		"let count = 0; map([1, 2, 3], fn(x) { count += x; count }); count",
+		"let f = fn(xs) { let total = 0; for (x in map(xs, fn(x) { x * x })) { total += x }; total }; f([1, 2, 3]) + 1",
+		"sort([3, 1, 2], fn(a, b) { a > b })", "map([1, 0], fn(x) { 1 / x })", "sort([1, 2], fn(a, b) { a + true })",
+		"map([1], fn(x) { return x + 1; 5 })", "map([1, 2], fn(x) { if (x > 1) { return 0 } })",
+		"reverse([1, 2, 3])", "concat([1], [], [2, 3])", "range(5, 0, -2)", "zip([1, 2, 3], [4, 5])", "flatten([1, [2, 3], [[4]]])",
+		"let myArray = [1,2,3]; let i = myArray[0]; myArray[i];",
+		`let two = "two"; {"one": 10 - 9, two: 1 + 1, "thr" + "ee": 6 / 2, 4: 4, true: 5}`,
+		`{"foo": 5}["bar"]`, `{true: 5}[true]`,
+
+		// equality
+		"[1, 2] == [1, 2]", "[1, [2]] != [1, [3]]", `{"a": [1]} == {"a": [1.0]}`, "[][0] == if (false) { 1 }", `1 == "1"`,
+		"let a = [1]; a[0] = a; let b = [1]; b[0] = b; [a == b, a]",
+		"let f = fn() { 1 }; [f == f, f == fn() { 1 }]", `contains([[1], 2], [1])`, `index_of(["a", "b"], "b")`,
+
+		// formatting
+		`format("%d + %d = %d", 1, 2, 3)`, `format("[%5d] [%-5d] [%05d]", 42, 42, -42)`, `format("%.2f|%6.1f", 3.14159, 2)`,
+		`format("%s %v %q", "a", [1, "b"], "c")`, `format("%d", "1")`, `format("%d %d", 1)`, `format("100%%")`,
+
+		// parameters
+		"let f = fn(a, b) { a + b }; f(1)", "let f = fn(a) { a }; f(1, 2)", "let add = fn(a, b = 10) { a + b }; [add(1), add(1, 2)]",
+		"let f = fn(a, b = a * 2, c = a + b) { [a, b, c] }; [f(1), f(1, 5), f(1, 5, 0)]", "let f = fn(a = 1) { a }; f(1, 2)",
+		"let f = fn(a, ...rest) { [a, rest] }; [f(1), f(1, 2, 3)]", "let f = fn(...xs) { len(xs) }; [f(), f(1, 2)]",
+		"let f = fn(a, b = 2, ...rest) { [a, b, rest] }; [f(1), f(1, 3), f(1, 3, 4, 5)]", "let f = fn(a, ...rest) { a }; f()",
+		"let n = 10; let f = fn(a, b = n) { let g = fn() { a + b }; g() }; [f(1), f(1, 2)]", "let f = fn(a = 1 / 0) { a }; f()",
+		"let f = fn(x, step = 1) { if (x > 3) { return x } f(x + step) }; [f(0), f(0, 2)]", "reduce([1, 2, 3], fn(acc, x, unused = 0) { acc + x + unused })",
+
+		// errors handled by try
+		`try { 1 / 0 } catch (e) { e["message"] }`, `try { throw error("oops") } catch (e) { e["message"] }`, `throw "top"`,
+		`let f = fn(x) { if (x > 1) { throw "too big: ${x}" } x }; [f(1), try { f(2) } catch (e) { e["message"] }]`,
+		`let n = 0; let r = try { throw "a" } catch (e) { n += 1; 5 } finally { n += 10 }; [n, r]`, "try { 1 } finally { 2 }",
+		`let n = 0; try { try { throw "a" } finally { n += 1 } } catch (e) { n += 10 }; n`, `try { throw 1 } catch (e) { }`,
+		"let f = fn() { try { return 1 } finally { return 2 } }; f()", `1 + try { throw "a" } catch (e) { 2 }`,
+		`let log = []; let f = fn() { try { throw "a" } catch (e) { return 5 } finally { log = push(log, "f") } }; [f(), log]`,
+		"let n = 0; for (x in [1, 2, 3]) { try { if (x == 2) { break } n += x } finally { n += 10 } }; n",
+		"let n = 0; while (true) { try { try { break } finally { n += 1 } } finally { n += 10 } }; n",
+		"let n = 0; for (x in [1, 2, 3]) { try { if (x == 2) { continue } n += x } finally { n += 100 } }; n",
+		`try { map([1, 2], fn(x) { if (x == 2) { throw "two" } x }) } catch (e) { e["message"] }`,
+		`map([1, 2], fn(x) { try { throw "in" } catch (e) { x * 10 } })`, `try { try { 1 } finally { throw "f" } } catch (e) { e["message"] }`,
+		`let f = fn(n) { if (n == 0) { throw "deep" } f(n - 1) }; [try { f(50) } catch (e) { e["message"] }, f(0)]`,
+		`let f = fn() { try { 1 / 0 } catch (e) { e["message"] } }; [f(), f(), try { 2 } catch (e) { 3 }]`,
+		`let f = fn() { 1 / 0 }; try { f() } catch (e) { [e["message"], e["stack"]] }`,
+		`let f = fn(n) { if (n == 0) { throw "deep" } f(n - 1) }; let g = fn() { try { f(2) } catch (e) { e["stack"] } }; g()`,
+		`try { map([1], fn(x) { x / 0 }) } catch (e) { e["stack"] }`, `let f = fn() { try { 1 / 0 } finally { 2 } }; f()`,
+		`let f = fn() { 1 / 0 }; let g = fn() { try { f() } catch (e) { throw e } }; g()`,
+		"let inner = fn(x) { x / 0 };\nlet outer = fn() { map([1], fn(x) { inner(x) }) };\nouter();",
+
+		// modules, which are found through MONKEYPATH
+		`import "math"; math.add(1, 2)`, `let m = import("math.monkey"); [m["pi"], m == import "math"]`,
+		`import("math").twice(fn(x) { x * 2 }, 3)`, `import "math"; map([1, 2], fn(x) { math.add(x, 10) })`,
+		`import "math"; math._secret`, `import "missing"`, `import 1`, `import "failing"`, `import "cycle"`,
+		`let h = {"a": {"b": 2}}; h.a.b`, `let h = {"a": 1}; h.b`, "let a = [1]; a.b",
+
+		// loops
+		"let i = 0; while (i < 5) { let i = i + 1; } i",
+		"let i = 0; while (true) { if (i == 3) { break; } let i = i + 1; } i",
+		"let n = 0; let i = 0; while (i < 5) { let i = i + 1; if (i == 2) { continue; } let n = n + i; } n",
+		"let n = 0; for (x in [1, 2, 3, 4]) { if (x == 2) { continue; } if (x == 4) { break; } let n = n + x; } n",
+		"let n = 0; for (x in [1, 2]) { for (y in [10, 20]) { if (y == 20) { break; } let n = n + x * y; } } n",
+		`let s = ""; for (c in "abc") { let s = c + s; } s`, `let s = ""; for (k in {"a": 1, "b": 2}) { let s = s + k; } s`,
+		"let f = fn(a) { let n = 0; for (x in a) { for (y in a) { let n = n + x * y; } } n }; f([1, 2, 3])",
+		"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x; } } 0 }; f()",
+		"for (x in 5) { x }; 1", "while (true) { 1 + true; }; 1",
+
+		// assignments
+		"let x = 1; x = 2; x", "let a = 1; let b = 2; a = b = 3; a + b", "let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x",
+		`let s = "a"; s += "b"; s`, "let n = 0; for (x in [1, 2, 3]) { n += x; } n",
+		"let newCounter = fn() { let count = 0; fn() { count += 1 } }; let c = newCounter(); c(); c(); c()",
+		"let total = 0; let add = fn(x) { total = total + x; }; add(1); add(2); total",
+		"let x = 1; let f = fn() { let x = 2; x = 3; x }; f() + x",
+		"let f = fn(n) { let g = fn() { n = n * 2 }; g(); g(); n }; f(3)",
+		"let f = fn() { let a = 1; let g = fn() { fn() { a += 1 } }; g()(); g()(); a }; f()",
+		"let f = fn() { let n = 0; let fs = [fn() { n += 1 }, fn() { n += 10 }]; fs[0](); fs[1](); n }; f()",
+		"let f = fn(n) { if (n == 0) { return 0; } let x = n; let g = fn() { x }; x = f(n - 1); g() }; f(3)",
+		"let a = [1, 2, 3]; a[1] = 5; a", "let a = [1, 2, 3]; let b = a; b[0] += 10; a",
+		`let h = {"a": 1}; h["b"] = 2; h["a"] *= 5; h`, "let a = [[1]]; a[0][0] = 2; a",
+		"let f = fn() { y = 1 }; f()", "let f = fn() { y = 1 }; let y = 0; f(); y", "x += 1", "let x = 1; x += true",
+		"let a = [1]; a[1] = 2", `let h = {}; h["a"] += 1`, `let s = "abc"; s[0] = "x"`,
+	}
+
+	for _, input := range inputs {
+		l := lexer.NewLexer(input)
+		p := parser.NewParser(l)
+		expected := evaluator.Eval(p.ParseProgram(), object.NewEnvironment())
+
+		actual, err := testRun(t, input)
+
+		if expectedErr, ok := expected.(*object.Error); ok {
+			if err == nil {
+				t.Errorf("no error for input `%s`. expected=`%s`, actual=`%s`", input, expectedErr.Message, actual.Inspect())
+				continue
+			}
+
+			runtimeErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Errorf("wrong error for input `%s`. expected=`%s`, actual=`%T(%s)`", input, expectedErr.Message, err, err)
+				continue
+			}
+
+			// the error has the same position and stack in both engines
+			if runtimeErr.Err.Traceback() != expectedErr.Traceback() {
+				t.Errorf("wrong error for input `%s`. expected=`%s`, actual=`%s`", input, expectedErr.Traceback(), runtimeErr.Err.Traceback())
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("vm error for input `%s`: %s", input, err)
+			continue
+		}
+
+		if expected == nil {
+			expected = evaluator.Null
+		}
+
+		if actual.Inspect() != expected.Inspect() {
+			t.Errorf("wrong result for input `%s`. expected=`%s`, actual=`%s`", input, expected.Inspect(), actual.Inspect())
+		}
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	input := "let f = fn(x) { x * 2 }; f(4611686018427387904)"
+
+	bytecode, err := compileWithState(input, compiler.NewSymbolTable(), []object.Object{})
+	if err != nil {
+		t.Fatalf("compilation failed: %s", err)
+	}
+
+	machine := New(bytecode)
+	machine.Evaluator.CheckedArithmetic = true
+
+	err = machine.Run()
+	if err == nil {
+		t.Fatalf("no vm error for input `%s`", input)
+	}
+
+	if err.Error() != "integer overflow: 4611686018427387904 * 2" {
+		t.Errorf("wrong vm error. actual=`%s`", err)
+	}
+}
+
+func TestCallingFunctionsWithWrongArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "fn() { 1; }(1);", expected: "wrong argument count for `<anonymous>` function. expected=`0`, actual=`1`"},
+		{input: "fn(a, b) { a + b; }(1);", expected: "wrong argument count for `<anonymous>` function. expected=`2`, actual=`1`"},
+		{input: "let f = fn(a, b = 2) { a + b; }; f(1, 2, 3);", expected: "wrong argument count for `f` function. expected=`1 to 2`, actual=`3`"},
+		{input: "let f = fn(a, ...rest) { a; }; f();", expected: "wrong argument count for `f` function. expected=`at least 1`, actual=`0`"},
+	}
+
+	for _, tt := range tests {
+		_, err := testRun(t, tt.input)
+		if err == nil {
+			t.Fatalf("expected vm error for input `%s` but resulted in none", tt.input)
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong vm error. expected=`%s`, actual=`%s`", tt.expected, err)
+		}
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	_, err := testRun(t, "let f = fn(x) { f(x + 1) }; f(0);")
+	if err == nil || err.Error() != "stack overflow" {
+		t.Fatalf("expected stack overflow error. actual=`%v`", err)
+	}
+}
+
+func TestGlobalsAcrossRuns(t *testing.T) {
+	globals := make([]object.Object, GlobalsSize)
+	symbolTable := compiler.NewSymbolTable()
+	constants := []object.Object{}
+
+	for i, input := range []string{"let a = 40;", "let f = fn(x) { a + x };", "f(2)"} {
+		bytecode, err := compileWithState(input, symbolTable, constants)
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+		constants = bytecode.Constants
+
+		machine := NewWithGlobalsStore(bytecode, globals)
+		err = machine.Run()
+		if err != nil {
+			t.Fatalf("vm error: %s", err)
+		}
+
+		if i == 2 {
+			result, ok := machine.LastResult().(*object.Integer)
+			if !ok || result.Value != 42 {
+				t.Fatalf("wrong result. expected=`42`, actual=`%v`", machine.LastResult())
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package vm
+
+import (
+	"monkey/compiler"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+// testRun compiles and runs `input`. Both compilation and runtime errors are reported through the returned error.
+func testRun(t *testing.T, input string) (object.Object, error) {
+	l := lexer.NewLexer(input)
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors for input `%s`: %v", input, p.Errors())
+	}
+
+	comp := compiler.New()
+	err := comp.Compile(program)
+	if err != nil {
+		return nil, err
+	}
+
+	machine := New(comp.Bytecode())
+	err = machine.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	return machine.LastResult(), nil
+}
+
+func compileWithState(input string, symbolTable *compiler.SymbolTable, constants []object.Object) (*compiler.Bytecode, error) {
+	l := lexer.NewLexer(input)
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+
+	comp := compiler.NewWithState(symbolTable, constants)
+	err := comp.Compile(program)
+	if err != nil {
+		return nil, err
+	}
+
+	return comp.Bytecode(), nil
+}